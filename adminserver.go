@@ -0,0 +1,115 @@
+package cloudrunner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime/debug"
+	"strconv"
+
+	"go.einride.tech/cloudrunner/cloudconfig"
+	"go.einride.tech/cloudrunner/cloudmux"
+	"google.golang.org/grpc"
+	channelzservice "google.golang.org/grpc/channelz/service"
+)
+
+// startAdmin starts the admin server on the configured admin address, as a background goroutine that is drained
+// with the public servers during graceful shutdown.
+func (r *runContext) startAdmin(ctx context.Context, config slog.LogValuer) {
+	r.goroutines.start(ctx, "admin server", func(ctx context.Context) error {
+		r.serveAdmin(ctx, config)
+		return nil
+	}, goConfig{})
+}
+
+// serveAdmin serves the admin server on the configured admin address until the context is canceled.
+//
+// The admin server serves gRPC channelz and HTTP debugging endpoints on the same port. It is intentionally not
+// chained through the middleware of the public servers, and failures are logged rather than failing the service.
+func (r *runContext) serveAdmin(ctx context.Context, config slog.LogValuer) {
+	address := net.JoinHostPort(r.config.Admin.Host, strconv.Itoa(r.config.Admin.Port))
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", address)
+	if err != nil {
+		slog.WarnContext(ctx, "admin server unable to listen", slog.Any("error", err))
+		return
+	}
+	// Begin the shutdown sequence before the admin server starts shutting down.
+	serveCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.beginShutdown(ctx)
+			cancel()
+		case <-serveCtx.Done():
+		}
+	}()
+	grpcServer := grpc.NewServer()
+	channelzservice.RegisterChannelzServiceToServer(grpcServer)
+	httpServer := &http.Server{
+		Handler:           newAdminHandler(config, &r.logLevel),
+		ReadHeaderTimeout: r.config.Server.Timeout,
+	}
+	slog.InfoContext(ctx, "admin server listening", slog.String("address", address))
+	if err := cloudmux.ServeGRPCHTTP(
+		serveCtx, listener, grpcServer, httpServer,
+		cloudmux.WithShutdownTimeout(r.drainTimeout()),
+	); err != nil {
+		slog.WarnContext(ctx, "admin server stopped", slog.Any("error", err))
+	}
+}
+
+// newAdminHandler returns the HTTP handler of the admin server.
+func newAdminHandler(config slog.LogValuer, logLevel *slog.LevelVar) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(cloudconfig.LogValueToAny(config.LogValue()))
+	})
+	mux.HandleFunc("GET /buildinfo", func(w http.ResponseWriter, _ *http.Request) {
+		buildInfo, ok := debug.ReadBuildInfo()
+		if !ok {
+			http.Error(w, "build info not available", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, buildInfo.String())
+	})
+	mux.HandleFunc("GET /loglevel", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, logLevel.Level().String())
+	})
+	mux.HandleFunc("PUT /loglevel", func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(io.LimitReader(r.Body, 64))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		previous := logLevel.Level()
+		if err := logLevel.UnmarshalText(data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.InfoContext(
+			r.Context(),
+			"log level changed",
+			slog.String("previous", previous.String()),
+			slog.String("level", logLevel.Level().String()),
+		)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, logLevel.Level().String())
+	})
+	return mux
+}
@@ -0,0 +1,117 @@
+package cloudrunner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+type testLogValuer []slog.Attr
+
+func (v testLogValuer) LogValue() slog.Value {
+	return slog.GroupValue(v...)
+}
+
+func TestAdminHandler(t *testing.T) {
+	t.Parallel()
+	serve := func(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return recorder
+	}
+
+	t.Run("config", func(t *testing.T) {
+		t.Parallel()
+		config := testLogValuer{
+			slog.Group("cloudrunner",
+				slog.Duration("SERVER_TIMEOUT", 290*time.Second),
+				slog.String("MY_SECRET", "<secret>"),
+			),
+		}
+		response := serve(newAdminHandler(config, &slog.LevelVar{}), http.MethodGet, "/config", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		const expected = `{
+  "cloudrunner": {
+    "MY_SECRET": "<secret>",
+    "SERVER_TIMEOUT": "4m50s"
+  }
+}
+`
+		assert.Equal(t, expected, response.Body.String())
+	})
+
+	t.Run("log level", func(t *testing.T) {
+		t.Parallel()
+		var logLevel slog.LevelVar
+		handler := newAdminHandler(testLogValuer{}, &logLevel)
+		response := serve(handler, http.MethodGet, "/loglevel", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "INFO", response.Body.String())
+		response = serve(handler, http.MethodPut, "/loglevel", "debug")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "DEBUG", response.Body.String())
+		assert.Equal(t, slog.LevelDebug, logLevel.Level())
+		response = serve(handler, http.MethodPut, "/loglevel", "foo")
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, slog.LevelDebug, logLevel.Level())
+	})
+
+	t.Run("pprof", func(t *testing.T) {
+		t.Parallel()
+		response := serve(newAdminHandler(testLogValuer{}, &slog.LevelVar{}), http.MethodGet, "/debug/pprof/", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+}
+
+func TestRunContext_startAdmin(t *testing.T) {
+	t.Parallel()
+	listener, err := (&net.ListenConfig{}).Listen(t.Context(), "tcp", "localhost:0")
+	assert.NilError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	assert.NilError(t, listener.Close())
+	run := newRunContext(nil)
+	run.config.Admin.Enabled = true
+	run.config.Admin.Host = "localhost"
+	run.config.Admin.Port = port
+	run.config.Shutdown.Timeout = 5 * time.Second
+	ctx, cancel := context.WithCancel(t.Context())
+	run.startAdmin(ctx, testLogValuer{})
+	get := func() (*http.Response, error) {
+		request, err := http.NewRequestWithContext(
+			t.Context(), http.MethodGet, fmt.Sprintf("http://localhost:%d/loglevel", port), nil,
+		)
+		assert.NilError(t, err)
+		return http.DefaultClient.Do(request)
+	}
+	assert.Assert(t, eventually(func() bool {
+		response, err := get()
+		if err != nil {
+			return false
+		}
+		_ = response.Body.Close()
+		return response.StatusCode == http.StatusOK
+	}))
+	cancel()
+	// The admin server is drained with the other background goroutines during graceful shutdown.
+	assert.NilError(t, run.goroutines.wait(run.beginShutdown(t.Context())))
+	_, err = get()
+	assert.Assert(t, err != nil)
+}
+
+func eventually(condition func() bool) bool {
+	for range 100 {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
@@ -0,0 +1,339 @@
+package cloudclient
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CircuitBreakerConfig configures the client-side circuit breaker for outgoing gRPC client calls.
+//
+// A circuit breaker is kept for each target and method. The breaker opens when the ratio of failed calls over the
+// sliding window exceeds the failure ratio, and calls then fail fast with Unavailable. After the open duration, the
+// breaker is half-open and lets a limited number of probe calls through, which close the breaker when successful.
+type CircuitBreakerConfig struct {
+	// Enabled indicates if the circuit breaker is enabled.
+	Enabled bool `desc:"Enable the client circuit breaker"`
+	// Window is the duration of the sliding window of calls to compute the failure ratio over.
+	Window time.Duration `default:"10s" min:"0" desc:"Sliding window to compute the failure ratio over"`
+	// MinRequests is the minimum number of calls in the window before the breaker can open.
+	MinRequests int `default:"20" min:"1" desc:"Minimum number of calls in the window before opening"`
+	// FailureRatio is the ratio of failed calls in the window that, when exceeded, opens the breaker.
+	// A ratio of zero opens the breaker on any failure, and a ratio of one never opens the breaker.
+	FailureRatio float64 `default:"0.5" min:"0" max:"1" desc:"Ratio of failed calls exceeded to open the breaker"`
+	// OpenDuration is the duration the breaker stays open before letting probe calls through.
+	OpenDuration time.Duration `default:"30s" min:"0" desc:"Duration the breaker stays open before probing"`
+	// HalfOpenRequests is the number of successful probe calls needed to close the breaker.
+	HalfOpenRequests int `default:"1" min:"1" desc:"Number of successful probe calls to close the breaker"`
+	// FailureStatusCodes is the set of status codes counted as failures.
+	FailureStatusCodes []codes.Code `default:"Unavailable,Unknown" desc:"Codes counted as failures"`
+}
+
+// WithCircuitBreaker returns a call option that overrides the circuit breaker config.
+//
+// Use with grpc.WithDefaultCallOptions to configure the circuit breaker of a target, or pass to a call to configure
+// the circuit breaker of a single call. Per-method overrides from the client config still apply.
+func WithCircuitBreaker(config CircuitBreakerConfig) grpc.CallOption {
+	return circuitBreakerCallOption{config: config}
+}
+
+type circuitBreakerCallOption struct {
+	grpc.EmptyCallOption
+	config CircuitBreakerConfig
+}
+
+// circuitBreakerState is the state of a circuit breaker.
+type circuitBreakerState int
+
+const (
+	circuitBreakerClosed circuitBreakerState = iota
+	circuitBreakerHalfOpen
+	circuitBreakerOpen
+)
+
+// String returns the name of the state.
+func (s circuitBreakerState) String() string {
+	switch s {
+	case circuitBreakerClosed:
+		return "closed"
+	case circuitBreakerHalfOpen:
+		return "half-open"
+	case circuitBreakerOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// circuitBreakerBuckets is the number of buckets of the sliding window.
+const circuitBreakerBuckets = 10
+
+type circuitBreakerBucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+// circuitBreakerKey identifies the circuit breaker of a method on a target.
+type circuitBreakerKey struct {
+	target     string
+	fullMethod string
+}
+
+// circuitBreaker tracks the outcome of calls to a method on a target.
+type circuitBreaker struct {
+	key      circuitBreakerKey
+	mu       sync.Mutex
+	state    circuitBreakerState
+	buckets  [circuitBreakerBuckets]circuitBreakerBucket
+	openedAt time.Time
+	// generation is incremented on every state change, to tell outcomes of calls admitted in an earlier state.
+	generation uint64
+	// probes is the number of probes in flight in the half-open state.
+	probes int
+	// successes is the number of successful probes in the half-open state.
+	successes int
+}
+
+// circuitBreakerAdmission is the state of the breaker when a call was admitted.
+type circuitBreakerAdmission struct {
+	generation uint64
+	state      circuitBreakerState
+}
+
+// allow returns the admission of a call, and true if the call may proceed.
+func (b *circuitBreaker) allow(
+	ctx context.Context,
+	config CircuitBreakerConfig,
+	now time.Time,
+) (circuitBreakerAdmission, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case circuitBreakerOpen:
+		if now.Sub(b.openedAt) < config.OpenDuration {
+			return circuitBreakerAdmission{}, false
+		}
+		b.transition(ctx, circuitBreakerHalfOpen)
+		fallthrough
+	case circuitBreakerHalfOpen:
+		if b.probes >= config.HalfOpenRequests {
+			return circuitBreakerAdmission{}, false
+		}
+		b.probes++
+	}
+	return circuitBreakerAdmission{generation: b.generation, state: b.state}, true
+}
+
+// record records the outcome of an admitted call.
+// Outcomes of calls admitted before the last state change are ignored.
+func (b *circuitBreaker) record(
+	ctx context.Context,
+	config CircuitBreakerConfig,
+	admission circuitBreakerAdmission,
+	now time.Time,
+	code codes.Code,
+) {
+	failure := isFailureCode(config, code)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if admission.generation != b.generation {
+		return
+	}
+	switch admission.state {
+	case circuitBreakerHalfOpen:
+		b.probes--
+		if failure {
+			b.openedAt = now
+			b.transition(ctx, circuitBreakerOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= config.HalfOpenRequests {
+			b.transition(ctx, circuitBreakerClosed)
+		}
+	case circuitBreakerClosed:
+		bucket := b.bucket(config, now)
+		bucket.requests++
+		if failure {
+			bucket.failures++
+		}
+		requests, failures := b.count(config, now)
+		if requests >= config.MinRequests && float64(failures) > config.FailureRatio*float64(requests) {
+			b.openedAt = now
+			b.transition(ctx, circuitBreakerOpen)
+		}
+	case circuitBreakerOpen:
+		// Calls are never admitted in the open state.
+	}
+}
+
+// bucket returns the bucket of the sliding window for the current time, resetting it when stale.
+func (b *circuitBreaker) bucket(config CircuitBreakerConfig, now time.Time) *circuitBreakerBucket {
+	width := config.Window / circuitBreakerBuckets
+	if width <= 0 {
+		width = time.Nanosecond
+	}
+	start := now.Truncate(width)
+	bucket := &b.buckets[int(start.UnixNano()/int64(width))%circuitBreakerBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = circuitBreakerBucket{start: start}
+	}
+	return bucket
+}
+
+// count returns the number of calls and failures in the sliding window.
+func (b *circuitBreaker) count(config CircuitBreakerConfig, now time.Time) (requests, failures int) {
+	for _, bucket := range b.buckets {
+		if now.Sub(bucket.start) < config.Window {
+			requests += bucket.requests
+			failures += bucket.failures
+		}
+	}
+	return requests, failures
+}
+
+// transition changes the state of the breaker, and logs and records the change.
+func (b *circuitBreaker) transition(ctx context.Context, state circuitBreakerState) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.probes, b.successes = 0, 0
+	if state == circuitBreakerClosed {
+		b.buckets = [circuitBreakerBuckets]circuitBreakerBucket{}
+	}
+	level := slog.LevelInfo
+	if state == circuitBreakerOpen {
+		level = slog.LevelWarn
+	}
+	slog.LogAttrs(
+		ctx,
+		level,
+		"circuit breaker state changed",
+		slog.String("target", b.key.target),
+		slog.String("fullMethod", b.key.fullMethod),
+		slog.String("from", from.String()),
+		slog.String("to", state.String()),
+	)
+	gauge, err := otel.Meter("go.einride.tech/cloudrunner").Int64Gauge(
+		"cloudrunner.client.circuit_breaker.state",
+		metric.WithDescription("State of client circuit breakers: 0 closed, 1 half-open, 2 open."),
+	)
+	if err != nil {
+		slog.WarnContext(ctx, "unable to record circuit breaker state", slog.Any("error", err))
+		return
+	}
+	gauge.Record(ctx, int64(state), metric.WithAttributes(b.attributes()...))
+}
+
+// recordRejection records a metric for a call rejected by the breaker.
+func (b *circuitBreaker) recordRejection(ctx context.Context) {
+	counter, err := otel.Meter("go.einride.tech/cloudrunner").Int64Counter(
+		"cloudrunner.client.circuit_breaker.rejections",
+		metric.WithDescription("Number of calls rejected by client circuit breakers."),
+	)
+	if err != nil {
+		slog.WarnContext(ctx, "unable to record circuit breaker rejection", slog.Any("error", err))
+		return
+	}
+	counter.Add(ctx, 1, metric.WithAttributes(b.attributes()...))
+}
+
+func (b *circuitBreaker) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("target", b.key.target),
+		attribute.String("rpc.method", b.key.fullMethod),
+	}
+}
+
+func isFailureCode(config CircuitBreakerConfig, code codes.Code) bool {
+	for _, failureCode := range config.FailureStatusCodes {
+		if code == failureCode {
+			return true
+		}
+	}
+	return false
+}
+
+// circuitBreakerConfig returns the circuit breaker config of a call, with overrides from call options and the
+// method config applied.
+func (l *Middleware) circuitBreakerConfig(fullMethod string, opts []grpc.CallOption) CircuitBreakerConfig {
+	config := l.Config.CircuitBreaker
+	for _, opt := range opts {
+		if opt, ok := opt.(circuitBreakerCallOption); ok {
+			config = opt.config
+		}
+	}
+	if enabled := l.Config.Methods.circuitBreaker(fullMethod); enabled != nil {
+		config.Enabled = *enabled
+	}
+	return config
+}
+
+// circuitBreaker returns the circuit breaker of a method on a target.
+func (l *Middleware) circuitBreaker(cc *grpc.ClientConn, fullMethod string) *circuitBreaker {
+	key := circuitBreakerKey{fullMethod: fullMethod}
+	if cc != nil {
+		key.target = cc.Target()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.circuitBreakers == nil {
+		l.circuitBreakers = make(map[circuitBreakerKey]*circuitBreaker)
+	}
+	breaker, ok := l.circuitBreakers[key]
+	if !ok {
+		breaker = &circuitBreaker{key: key}
+		l.circuitBreakers[key] = breaker
+	}
+	return breaker
+}
+
+// withCircuitBreaker calls fn when the circuit breaker of the method allows it, and records the outcome.
+func (l *Middleware) withCircuitBreaker(
+	ctx context.Context,
+	cc *grpc.ClientConn,
+	fullMethod string,
+	opts []grpc.CallOption,
+	fn func() error,
+) error {
+	record, err := l.admitCircuitBreaker(ctx, cc, fullMethod, opts)
+	if err != nil {
+		return err
+	}
+	err = fn()
+	if record != nil {
+		record(err)
+	}
+	return err
+}
+
+// admitCircuitBreaker returns an error when the circuit breaker of the method rejects a call.
+// Admitted calls must record their outcome with the returned function, which is nil when the breaker is disabled.
+func (l *Middleware) admitCircuitBreaker(
+	ctx context.Context,
+	cc *grpc.ClientConn,
+	fullMethod string,
+	opts []grpc.CallOption,
+) (func(error), error) {
+	config := l.circuitBreakerConfig(fullMethod, opts)
+	if !config.Enabled {
+		return nil, nil
+	}
+	breaker := l.circuitBreaker(cc, fullMethod)
+	admission, ok := breaker.allow(ctx, config, time.Now())
+	if !ok {
+		breaker.recordRejection(ctx)
+		return nil, status.Errorf(codes.Unavailable, "circuit breaker open for %s", fullMethod)
+	}
+	return func(err error) {
+		breaker.record(ctx, config, admission, time.Now(), status.Code(err))
+	}, nil
+}
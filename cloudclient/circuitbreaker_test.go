@@ -0,0 +1,303 @@
+package cloudclient
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"gotest.tools/v3/assert"
+)
+
+func TestMiddleware_circuitBreaker(t *testing.T) {
+	newMiddleware := func() *Middleware {
+		return &Middleware{
+			Config: Config{
+				CircuitBreaker: CircuitBreakerConfig{
+					Enabled:            true,
+					Window:             time.Minute,
+					MinRequests:        4,
+					FailureRatio:       0.5,
+					OpenDuration:       10 * time.Millisecond,
+					HalfOpenRequests:   1,
+					FailureStatusCodes: []codes.Code{codes.Unavailable},
+				},
+			},
+		}
+	}
+	call := func(middleware *Middleware, fullMethod string, code codes.Code, opts ...grpc.CallOption) (bool, error) {
+		var invoked bool
+		invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			invoked = true
+			return status.Error(code, "error")
+		}
+		err := middleware.GRPCUnaryClientInterceptor(context.Background(), fullMethod, nil, nil, nil, invoker, opts...)
+		return invoked, err
+	}
+
+	t.Run("opens, half-opens and closes", func(t *testing.T) {
+		middleware := newMiddleware()
+		for _, code := range []codes.Code{codes.OK, codes.Unavailable, codes.Unavailable, codes.Unavailable} {
+			invoked, _ := call(middleware, "/test.Service/Method", code)
+			assert.Assert(t, invoked)
+		}
+		invoked, err := call(middleware, "/test.Service/Method", codes.OK)
+		assert.Assert(t, !invoked)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		// Other methods have separate breakers.
+		invoked, _ = call(middleware, "/test.Service/OtherMethod", codes.OK)
+		assert.Assert(t, invoked)
+		time.Sleep(20 * time.Millisecond)
+		invoked, err = call(middleware, "/test.Service/Method", codes.OK)
+		assert.Assert(t, invoked)
+		assert.NilError(t, err)
+		invoked, _ = call(middleware, "/test.Service/Method", codes.OK)
+		assert.Assert(t, invoked)
+	})
+
+	t.Run("stays closed at the failure ratio", func(t *testing.T) {
+		middleware := newMiddleware()
+		for _, code := range []codes.Code{codes.OK, codes.Unavailable, codes.OK, codes.Unavailable, codes.OK} {
+			invoked, _ := call(middleware, "/test.Service/Method", code)
+			assert.Assert(t, invoked)
+		}
+	})
+
+	t.Run("zero failure ratio opens on any failure", func(t *testing.T) {
+		middleware := newMiddleware()
+		middleware.Config.CircuitBreaker.FailureRatio = 0
+		for range 10 {
+			invoked, _ := call(middleware, "/test.Service/Method", codes.OK)
+			assert.Assert(t, invoked)
+		}
+		invoked, _ := call(middleware, "/test.Service/Method", codes.Unavailable)
+		assert.Assert(t, invoked)
+		invoked, _ = call(middleware, "/test.Service/Method", codes.OK)
+		assert.Assert(t, !invoked)
+	})
+
+	t.Run("reopens on failed probe", func(t *testing.T) {
+		middleware := newMiddleware()
+		for range 4 {
+			_, _ = call(middleware, "/test.Service/Method", codes.Unavailable)
+		}
+		time.Sleep(20 * time.Millisecond)
+		invoked, _ := call(middleware, "/test.Service/Method", codes.Unavailable)
+		assert.Assert(t, invoked)
+		invoked, _ = call(middleware, "/test.Service/Method", codes.OK)
+		assert.Assert(t, !invoked)
+	})
+
+	t.Run("ignores non-failure codes", func(t *testing.T) {
+		middleware := newMiddleware()
+		for range 10 {
+			invoked, _ := call(middleware, "/test.Service/Method", codes.NotFound)
+			assert.Assert(t, invoked)
+		}
+	})
+
+	t.Run("method override", func(t *testing.T) {
+		middleware := newMiddleware()
+		disabled := false
+		middleware.Config.Methods = MethodConfigs{{Name: "test.Service", CircuitBreaker: &disabled}}
+		for range 10 {
+			invoked, _ := call(middleware, "/test.Service/Method", codes.Unavailable)
+			assert.Assert(t, invoked)
+		}
+	})
+
+	t.Run("call option", func(t *testing.T) {
+		middleware := newMiddleware()
+		config := middleware.Config.CircuitBreaker
+		config.Enabled = false
+		for range 10 {
+			invoked, _ := call(middleware, "/test.Service/Method", codes.Unavailable, WithCircuitBreaker(config))
+			assert.Assert(t, invoked)
+		}
+	})
+}
+
+func TestMiddleware_circuitBreaker_streams(t *testing.T) {
+	newMiddleware := func() *Middleware {
+		return &Middleware{
+			Config: Config{
+				CircuitBreaker: CircuitBreakerConfig{
+					Enabled:            true,
+					Window:             time.Minute,
+					MinRequests:        2,
+					FailureRatio:       0.5,
+					OpenDuration:       time.Minute,
+					HalfOpenRequests:   1,
+					FailureStatusCodes: []codes.Code{codes.Unavailable},
+				},
+			},
+		}
+	}
+	serverStreams := &grpc.StreamDesc{ServerStreams: true}
+	newStream := func(
+		ctx context.Context,
+		middleware *Middleware,
+		desc *grpc.StreamDesc,
+		clientStream grpc.ClientStream,
+	) (grpc.ClientStream, bool, error) {
+		var opened bool
+		stream, err := middleware.GRPCStreamClientInterceptor(
+			ctx,
+			desc,
+			nil,
+			"/test.Service/Stream",
+			func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+				opened = true
+				return clientStream, nil
+			},
+		)
+		return stream, opened, err
+	}
+	isOpen := func(middleware *Middleware) bool {
+		_, opened, err := newStream(context.Background(), middleware, serverStreams, &fakeClientStream{})
+		if !opened {
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+		}
+		return !opened
+	}
+	count := func(middleware *Middleware) (requests, failures int) {
+		breaker := middleware.circuitBreaker(nil, "/test.Service/Stream")
+		breaker.mu.Lock()
+		defer breaker.mu.Unlock()
+		return breaker.count(middleware.Config.CircuitBreaker, time.Now())
+	}
+
+	t.Run("records failures received after opening the stream", func(t *testing.T) {
+		middleware := newMiddleware()
+		for i := range 2 {
+			stream, opened, err := newStream(context.Background(), middleware, serverStreams, &fakeClientStream{
+				responses: 1,
+				err:       status.Error(codes.Unavailable, "unavailable"),
+			})
+			assert.NilError(t, err)
+			assert.Assert(t, opened)
+			assert.NilError(t, stream.RecvMsg(&emptypb.Empty{}))
+			// The outcome is recorded on the final status, not when the stream is opened.
+			requests, _ := count(middleware)
+			assert.Equal(t, i, requests)
+			assert.Equal(t, codes.Unavailable, status.Code(stream.RecvMsg(&emptypb.Empty{})))
+		}
+		assert.Assert(t, isOpen(middleware))
+	})
+
+	t.Run("records successes on EOF", func(t *testing.T) {
+		middleware := newMiddleware()
+		for range 4 {
+			stream, _, err := newStream(context.Background(), middleware, serverStreams, &fakeClientStream{responses: 1})
+			assert.NilError(t, err)
+			assert.NilError(t, stream.RecvMsg(&emptypb.Empty{}))
+			assert.Equal(t, io.EOF, stream.RecvMsg(&emptypb.Empty{}))
+		}
+		assert.Assert(t, !isOpen(middleware))
+	})
+
+	t.Run("records success after the response of client streams", func(t *testing.T) {
+		middleware := newMiddleware()
+		stream, _, err := newStream(
+			context.Background(), middleware, &grpc.StreamDesc{ClientStreams: true}, &fakeClientStream{responses: 1},
+		)
+		assert.NilError(t, err)
+		assert.NilError(t, stream.RecvMsg(&emptypb.Empty{}))
+		requests, failures := count(middleware)
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, 0, failures)
+	})
+
+	t.Run("records abandoned streams when the context is done", func(t *testing.T) {
+		middleware := newMiddleware()
+		middleware.Config.CircuitBreaker.FailureStatusCodes = []codes.Code{codes.Canceled}
+		for range 2 {
+			ctx, cancel := context.WithCancel(context.Background())
+			stream, _, err := newStream(ctx, middleware, serverStreams, &fakeClientStream{responses: 2})
+			assert.NilError(t, err)
+			assert.NilError(t, stream.RecvMsg(&emptypb.Empty{}))
+			cancel()
+		}
+		breaker := middleware.circuitBreaker(nil, "/test.Service/Stream")
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			breaker.mu.Lock()
+			state := breaker.state
+			breaker.mu.Unlock()
+			if state == circuitBreakerOpen {
+				return
+			}
+		}
+		t.Fatal("timed out waiting for the circuit breaker to open")
+	})
+}
+
+// fakeClientStream is a grpc.ClientStream that receives a number of responses, followed by a final status.
+type fakeClientStream struct {
+	grpc.ClientStream
+	responses int
+	err       error
+}
+
+func (f *fakeClientStream) RecvMsg(interface{}) error {
+	if f.responses > 0 {
+		f.responses--
+		return nil
+	}
+	if f.err != nil {
+		return f.err
+	}
+	return io.EOF
+}
+
+func TestCircuitBreaker_staleOutcomes(t *testing.T) {
+	ctx := context.Background()
+	config := CircuitBreakerConfig{
+		Window:             time.Minute,
+		MinRequests:        2,
+		FailureRatio:       0.5,
+		OpenDuration:       time.Second,
+		HalfOpenRequests:   1,
+		FailureStatusCodes: []codes.Code{codes.Unavailable},
+	}
+	for _, tt := range []struct {
+		name          string
+		staleCode     codes.Code
+		expectedState circuitBreakerState
+	}{
+		{name: "stale success does not close", staleCode: codes.OK, expectedState: circuitBreakerHalfOpen},
+		{name: "stale failure does not reopen", staleCode: codes.Unavailable, expectedState: circuitBreakerHalfOpen},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var breaker circuitBreaker
+			now := time.Now()
+			// A slow call is admitted while the breaker is closed.
+			slow, ok := breaker.allow(ctx, config, now)
+			assert.Assert(t, ok)
+			for range 2 {
+				admission, ok := breaker.allow(ctx, config, now)
+				assert.Assert(t, ok)
+				breaker.record(ctx, config, admission, now, codes.Unavailable)
+			}
+			assert.Equal(t, circuitBreakerOpen, breaker.state)
+			// After the open duration, a probe is admitted in the half-open state.
+			now = now.Add(config.OpenDuration)
+			probe, ok := breaker.allow(ctx, config, now)
+			assert.Assert(t, ok)
+			assert.Equal(t, circuitBreakerHalfOpen, breaker.state)
+			// The slow call completes while the probe is in flight.
+			breaker.record(ctx, config, slow, now, tt.staleCode)
+			assert.Equal(t, tt.expectedState, breaker.state)
+			assert.Equal(t, 1, breaker.probes)
+			_, ok = breaker.allow(ctx, config, now)
+			assert.Assert(t, !ok, "only one probe may be in flight")
+			// The probe decides the state.
+			breaker.record(ctx, config, probe, now, codes.OK)
+			assert.Equal(t, circuitBreakerClosed, breaker.state)
+			assert.Equal(t, 0, breaker.probes)
+		})
+	}
+}
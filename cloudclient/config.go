@@ -9,20 +9,26 @@ import (
 	"google.golang.org/grpc/codes"
 )
 
-// Config configures a gRPC client's default timeout and retry behavior.
+// Config configures a gRPC client's default timeout, retry and hedging behavior.
 // See: https://github.com/grpc/grpc-proto/blob/master/grpc/service_config/service_config.proto
 type Config struct {
 	// The timeout of outgoing gRPC method calls. Set to zero to disable.
-	Timeout time.Duration `default:"10s"`
+	Timeout time.Duration `default:"10s" min:"0" desc:"Timeout of outgoing gRPC method calls, zero to disable"`
 	// Retry config.
 	Retry RetryConfig
+	// Hedging config. Mutually exclusive with Retry, and never applies to methods with a retry override.
+	Hedging HedgingConfig
+	// CircuitBreaker config.
+	CircuitBreaker CircuitBreakerConfig
+	// Methods overrides the timeout and retry config of specific services and methods.
+	Methods MethodConfigs `desc:"Per-service and per-method overrides, as name:timeout=10s,retry=false,maxAttempts=3;..."`
 }
 
 // RetryConfig configures default retry behavior for outgoing gRPC client calls.
 // See: https://github.com/grpc/grpc-proto/blob/master/grpc/service_config/service_config.proto
 type RetryConfig struct {
 	// Enabled indicates if retries are enabled.
-	Enabled bool `default:"true"`
+	Enabled bool `default:"true" desc:"Enable retries"`
 	// InitialBackoff is the initial exponential backoff duration.
 	//
 	// The initial retry attempt will occur at:
@@ -32,67 +38,161 @@ type RetryConfig struct {
 	//   random(0, min(initial_backoff*backoff_multiplier**(n-1), max_backoff)).
 	//
 	// Must be greater than zero.
-	InitialBackoff time.Duration `default:"200ms"`
+	InitialBackoff time.Duration `default:"200ms" desc:"Initial exponential backoff duration"`
 	// MaxBackoff is the maximum duration between retries.
-	MaxBackoff time.Duration `default:"60s"`
+	MaxBackoff time.Duration `default:"60s" desc:"Maximum duration between retries"`
 	// MaxAttempts is the max number of backoff attempts retried.
-	MaxAttempts int `default:"5"`
+	MaxAttempts int `default:"5" desc:"Maximum number of attempts"`
 	// BackoffMultiplier is the exponential backoff multiplier.
-	BackoffMultiplier float64 `default:"2"`
+	BackoffMultiplier float64 `default:"2" min:"1" desc:"Exponential backoff multiplier"`
 	// RetryableStatusCodes is the set of status codes which may be retried.
 	// Unknown status codes are retried by default for the sake of retrying Google Cloud HTTP load balancer errors.
-	RetryableStatusCodes []codes.Code `default:"Unavailable,Unknown"`
+	RetryableStatusCodes []codes.Code `default:"Unavailable,Unknown" desc:"Status codes which may be retried"`
 }
 
-// AsServiceConfigJSON returns the default method call config as a valid gRPC service JSON config.
-func (c *Config) AsServiceConfigJSON() string {
-	type methodNameJSON struct {
-		Service string `json:"service"`
-		Method  string `json:"method"`
-	}
-	type retryPolicyJSON struct {
-		MaxAttempts          int      `json:"maxAttempts"`
-		MaxBackoff           string   `json:"maxBackoff"`
-		InitialBackoff       string   `json:"initialBackoff"`
-		BackoffMultiplier    float64  `json:"backoffMultiplier"`
-		RetryableStatusCodes []string `json:"retryableStatusCodes"`
-	}
-	type methodConfigJSON struct {
-		Name        []methodNameJSON `json:"name"`
-		Timeout     *string          `json:"timeout,omitempty"`
-		RetryPolicy *retryPolicyJSON `json:"retryPolicy,omitempty"`
+// HedgingConfig configures hedged requests for outgoing gRPC client calls, where additional attempts are sent
+// without waiting for a response to the previous attempts.
+//
+// Hedging applies to unary calls through the client Middleware, since grpc-go does not implement the hedging
+// policy of the service config. Streams are never hedged.
+// See: https://github.com/grpc/proposal/blob/master/A6-client-retries.md#hedging-policy
+type HedgingConfig struct {
+	// Enabled indicates if hedging is enabled. Requires retries to be disabled.
+	Enabled bool `desc:"Enable hedged requests, requires retries to be disabled"`
+	// MaxAttempts is the max number of attempts sent, including the original request.
+	MaxAttempts int `default:"3" min:"2" desc:"Maximum number of attempts"`
+	// HedgingDelay is the delay between sending attempts.
+	// Set to zero to send all attempts immediately.
+	HedgingDelay time.Duration `default:"500ms" min:"0" desc:"Delay between sending attempts"`
+	// NonFatalStatusCodes is the set of status codes which do not cancel outstanding attempts.
+	NonFatalStatusCodes []codes.Code `default:"Unavailable,Unknown" desc:"Status codes which do not cancel other attempts"`
+}
+
+// Validate implements the Validator interface of cloudconfig.
+func (c *Config) Validate() error {
+	if c.Retry.Enabled && c.Hedging.Enabled {
+		return fmt.Errorf("retry and hedging are mutually exclusive, disable retries to enable hedging")
 	}
+	return nil
+}
+
+// AsServiceConfigJSON returns the default method call config, and the config of each method override, as a valid
+// gRPC service JSON config.
+//
+// The service config has no hedging policy, since hedging is implemented by the client Middleware.
+func (c *Config) AsServiceConfigJSON() string {
 	type serviceConfigJSON struct {
 		MethodConfig []methodConfigJSON `json:"methodConfig"`
 	}
+	methodConfigs := make([]methodConfigJSON, 0, 1+len(c.Methods))
+	// No service or method specified means the config applies to all methods, all services.
+	methodConfigs = append(
+		methodConfigs,
+		c.methodConfigJSON(methodNameJSON{}, c.Timeout, c.Retry.Enabled, c.Retry.MaxAttempts),
+	)
+	for _, method := range c.Methods {
+		timeout, retry, maxAttempts := c.Timeout, c.Retry.Enabled, c.Retry.MaxAttempts
+		if method.Timeout != nil {
+			timeout = *method.Timeout
+		}
+		if method.Retry != nil {
+			retry = *method.Retry
+		}
+		if method.MaxAttempts != nil {
+			maxAttempts = *method.MaxAttempts
+		}
+		service, methodName := method.serviceAndMethod()
+		name := methodNameJSON{Service: service, Method: methodName}
+		methodConfigs = append(methodConfigs, c.methodConfigJSON(name, timeout, retry, maxAttempts))
+	}
 	var s strings.Builder
-	methodConfig := methodConfigJSON{
-		Name: []methodNameJSON{
-			{}, // no service or method specified means the config applies to all methods, all services.
-		},
+	if err := json.NewEncoder(&s).Encode(serviceConfigJSON{MethodConfig: methodConfigs}); err != nil {
+		panic(err)
 	}
-	if c.Timeout > 0 {
+	return strings.TrimSpace(s.String())
+}
+
+type methodNameJSON struct {
+	Service string `json:"service"`
+	Method  string `json:"method"`
+}
+
+type retryPolicyJSON struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfigJSON struct {
+	Name        []methodNameJSON `json:"name"`
+	Timeout     *string          `json:"timeout,omitempty"`
+	RetryPolicy *retryPolicyJSON `json:"retryPolicy,omitempty"`
+}
+
+func (c *Config) methodConfigJSON(
+	name methodNameJSON,
+	timeout time.Duration,
+	retry bool,
+	maxAttempts int,
+) methodConfigJSON {
+	methodConfig := methodConfigJSON{Name: []methodNameJSON{name}}
+	if timeout > 0 {
 		methodConfig.Timeout = new(string)
-		*methodConfig.Timeout = fmt.Sprintf("%gs", c.Timeout.Seconds())
+		*methodConfig.Timeout = fmt.Sprintf("%gs", timeout.Seconds())
 	}
-	if c.Retry.Enabled {
+	if retry {
 		methodConfig.RetryPolicy = &retryPolicyJSON{
-			MaxAttempts:          c.Retry.MaxAttempts,
+			MaxAttempts:          maxAttempts,
 			InitialBackoff:       fmt.Sprintf("%gs", c.Retry.InitialBackoff.Seconds()),
 			MaxBackoff:           fmt.Sprintf("%gs", c.Retry.MaxBackoff.Seconds()),
 			BackoffMultiplier:    c.Retry.BackoffMultiplier,
-			RetryableStatusCodes: make([]string, 0, len(c.Retry.RetryableStatusCodes)),
+			RetryableStatusCodes: statusCodesJSON(c.Retry.RetryableStatusCodes),
+		}
+	}
+	return methodConfig
+}
+
+// hedgingPolicy returns the hedging policy of a full method, as /package.Service/Method, and false when calls of the
+// method are not hedged.
+//
+// Hedging never applies to methods with a retry override: methods with retry=true use the retry policy, and methods
+// with retry=false, such as non-idempotent mutations, are sent exactly once.
+func (c *Config) hedgingPolicy(fullMethod string) (hedgingPolicy, bool) {
+	if !c.Hedging.Enabled || c.Retry.Enabled {
+		return hedgingPolicy{}, false
+	}
+	policy := hedgingPolicy{maxAttempts: c.Hedging.MaxAttempts, timeout: c.Timeout}
+	if method, ok := c.Methods.find(fullMethod); ok {
+		if method.Retry != nil {
+			return hedgingPolicy{}, false
 		}
-		for _, code := range c.Retry.RetryableStatusCodes {
-			methodConfig.RetryPolicy.RetryableStatusCodes = append(
-				methodConfig.RetryPolicy.RetryableStatusCodes, strings.ToUpper(code.String()),
-			)
+		if method.Timeout != nil {
+			policy.timeout = *method.Timeout
+		}
+		if method.MaxAttempts != nil {
+			policy.maxAttempts = *method.MaxAttempts
 		}
 	}
-	if err := json.NewEncoder(&s).Encode(serviceConfigJSON{
-		MethodConfig: []methodConfigJSON{methodConfig},
-	}); err != nil {
-		panic(err)
+	if policy.maxAttempts < 2 {
+		return hedgingPolicy{}, false
 	}
-	return strings.TrimSpace(s.String())
+	return policy, true
+}
+
+// hedgingPolicy is the hedging policy of a method.
+type hedgingPolicy struct {
+	// maxAttempts is the max number of attempts sent, including the original request.
+	maxAttempts int
+	// timeout of the call, including all attempts. Zero when disabled.
+	timeout time.Duration
+}
+
+func statusCodesJSON(statusCodes []codes.Code) []string {
+	result := make([]string, 0, len(statusCodes))
+	for _, code := range statusCodes {
+		result = append(result, strings.ToUpper(code.String()))
+	}
+	return result
 }
@@ -28,3 +28,145 @@ func TestClientConfig_AsServiceConfigJSON(t *testing.T) {
 		`"retryableStatusCodes":["UNAVAILABLE","UNKNOWN"]}}]}`
 	assert.Equal(t, expected, input.AsServiceConfigJSON())
 }
+
+func TestClientConfig_AsServiceConfigJSON_methods(t *testing.T) {
+	var methods MethodConfigs
+	assert.NilError(t, methods.Set("my.pkg.Svc/LongExport:timeout=120s,retry=false;my.pkg.OtherSvc:maxAttempts=3"))
+	input := Config{
+		Timeout: 10 * time.Second,
+		Retry: RetryConfig{
+			Enabled:              true,
+			InitialBackoff:       200 * time.Millisecond,
+			MaxBackoff:           time.Minute,
+			MaxAttempts:          5,
+			BackoffMultiplier:    2,
+			RetryableStatusCodes: []codes.Code{codes.Unavailable},
+		},
+		Methods: methods,
+	}
+	const expected = `{"methodConfig":[` +
+		`{"name":[{"service":"","method":""}],"timeout":"10s",` +
+		`"retryPolicy":{"maxAttempts":5,"maxBackoff":"60s","initialBackoff":"0.2s","backoffMultiplier":2,` +
+		`"retryableStatusCodes":["UNAVAILABLE"]}},` +
+		`{"name":[{"service":"my.pkg.Svc","method":"LongExport"}],"timeout":"120s"},` +
+		`{"name":[{"service":"my.pkg.OtherSvc","method":""}],"timeout":"10s",` +
+		`"retryPolicy":{"maxAttempts":3,"maxBackoff":"60s","initialBackoff":"0.2s","backoffMultiplier":2,` +
+		`"retryableStatusCodes":["UNAVAILABLE"]}}]}`
+	assert.Equal(t, expected, input.AsServiceConfigJSON())
+}
+
+func TestClientConfig_AsServiceConfigJSON_hedging(t *testing.T) {
+	var methods MethodConfigs
+	assert.NilError(t, methods.Set("my.pkg.Svc/Get:maxAttempts=2;my.pkg.Svc/Update:retry=true"))
+	input := Config{
+		Timeout: 10 * time.Second,
+		Retry: RetryConfig{
+			InitialBackoff:       200 * time.Millisecond,
+			MaxBackoff:           time.Minute,
+			MaxAttempts:          5,
+			BackoffMultiplier:    2,
+			RetryableStatusCodes: []codes.Code{codes.Unavailable},
+		},
+		Hedging: HedgingConfig{
+			Enabled:             true,
+			MaxAttempts:         3,
+			HedgingDelay:        500 * time.Millisecond,
+			NonFatalStatusCodes: []codes.Code{codes.Unavailable, codes.Unknown},
+		},
+		Methods: methods,
+	}
+	assert.NilError(t, input.Validate())
+	// Hedging is implemented by the middleware, since grpc-go ignores hedging policies.
+	const expected = `{"methodConfig":[` +
+		`{"name":[{"service":"","method":""}],"timeout":"10s"},` +
+		`{"name":[{"service":"my.pkg.Svc","method":"Get"}],"timeout":"10s"},` +
+		`{"name":[{"service":"my.pkg.Svc","method":"Update"}],"timeout":"10s",` +
+		`"retryPolicy":{"maxAttempts":5,"maxBackoff":"60s","initialBackoff":"0.2s","backoffMultiplier":2,` +
+		`"retryableStatusCodes":["UNAVAILABLE"]}}]}`
+	assert.Equal(t, expected, input.AsServiceConfigJSON())
+}
+
+func TestClientConfig_hedgingPolicy(t *testing.T) {
+	var methods MethodConfigs
+	assert.NilError(t, methods.Set(
+		"my.pkg.Svc:timeout=5s;my.pkg.Svc/Get:maxAttempts=2;my.pkg.Svc/Update:retry=true;my.pkg.Svc/Create:retry=false",
+	))
+	input := Config{
+		Timeout: 10 * time.Second,
+		Hedging: HedgingConfig{Enabled: true, MaxAttempts: 3},
+		Methods: methods,
+	}
+	for _, tt := range []struct {
+		fullMethod string
+		expected   hedgingPolicy
+		hedged     bool
+	}{
+		{
+			fullMethod: "/my.pkg.OtherSvc/Get",
+			expected:   hedgingPolicy{maxAttempts: 3, timeout: 10 * time.Second},
+			hedged:     true,
+		},
+		{
+			fullMethod: "/my.pkg.Svc/List",
+			expected:   hedgingPolicy{maxAttempts: 3, timeout: 5 * time.Second},
+			hedged:     true,
+		},
+		{
+			// Method configs take precedence over service configs.
+			fullMethod: "/my.pkg.Svc/Get",
+			expected:   hedgingPolicy{maxAttempts: 2, timeout: 10 * time.Second},
+			hedged:     true,
+		},
+		{fullMethod: "/my.pkg.Svc/Update"},
+		{fullMethod: "/my.pkg.Svc/Create"},
+	} {
+		t.Run(tt.fullMethod, func(t *testing.T) {
+			actual, ok := input.hedgingPolicy(tt.fullMethod)
+			assert.Equal(t, tt.hedged, ok)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+	t.Run("disabled", func(t *testing.T) {
+		_, ok := (&Config{Hedging: HedgingConfig{MaxAttempts: 3}}).hedgingPolicy("/my.pkg.Svc/Get")
+		assert.Assert(t, !ok)
+	})
+}
+
+func TestClientConfig_Validate(t *testing.T) {
+	input := Config{
+		Retry:   RetryConfig{Enabled: true},
+		Hedging: HedgingConfig{Enabled: true},
+	}
+	assert.ErrorContains(t, input.Validate(), "mutually exclusive")
+	input.Retry.Enabled = false
+	assert.NilError(t, input.Validate())
+}
+
+func TestMethodConfigs_Set(t *testing.T) {
+	t.Run("env", func(t *testing.T) {
+		var methods MethodConfigs
+		assert.NilError(t, methods.Set("my.pkg.Svc/LongExport:timeout=120s,retry=false; my.pkg.OtherSvc:maxAttempts=3"))
+		assert.Equal(t, "my.pkg.Svc/LongExport:timeout=2m0s,retry=false;my.pkg.OtherSvc:maxAttempts=3", methods.String())
+	})
+	t.Run("json", func(t *testing.T) {
+		var methods MethodConfigs
+		assert.NilError(t, methods.Set(`[{"name":"my.pkg.Svc/LongExport","timeout":"120s","retry":false}]`))
+		assert.Equal(t, "my.pkg.Svc/LongExport:timeout=2m0s,retry=false", methods.String())
+	})
+	t.Run("circuit breaker", func(t *testing.T) {
+		var methods MethodConfigs
+		assert.NilError(t, methods.Set("my.pkg.Svc:circuitBreaker=true;my.pkg.Svc/Export:circuitBreaker=false"))
+		assert.Equal(t, "my.pkg.Svc:circuitBreaker=true;my.pkg.Svc/Export:circuitBreaker=false", methods.String())
+		assert.Equal(t, true, *methods.circuitBreaker("/my.pkg.Svc/Get"))
+		assert.Equal(t, false, *methods.circuitBreaker("/my.pkg.Svc/Export"))
+		assert.Assert(t, methods.circuitBreaker("/my.pkg.OtherSvc/Get") == nil)
+	})
+	t.Run("errors", func(t *testing.T) {
+		var methods MethodConfigs
+		assert.ErrorContains(t, methods.Set("my.pkg.Svc:timeout=x"), "invalid duration")
+		assert.ErrorContains(t, methods.Set("my.pkg.Svc:foo=bar"), "unknown key")
+		assert.ErrorContains(t, methods.Set("my.pkg.Svc:maxAttempts=1"), "at least 2")
+		assert.ErrorContains(t, methods.Set("a/b/c:retry=false"), "expected name")
+		assert.ErrorContains(t, methods.Set("my.pkg.Svc:retry=false;my.pkg.Svc:timeout=1s"), "duplicate name")
+	})
+}
@@ -0,0 +1,132 @@
+package cloudclient
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// invokeHedged sends up to the max number of attempts of a unary call, each after the hedging delay, or immediately
+// after an attempt fails with a non-fatal status code.
+//
+// The first attempt that succeeds or fails with a fatal status code is committed: its response, headers and trailers
+// are returned to the caller, and the outstanding attempts are canceled. When all attempts fail with non-fatal status
+// codes, the last attempt is committed.
+// See: https://github.com/grpc/proposal/blob/master/A6-client-retries.md#hedging-policy
+func (l *Middleware) invokeHedged(
+	ctx context.Context,
+	policy hedgingPolicy,
+	fullMethod string,
+	request interface{},
+	response proto.Message,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts []grpc.CallOption,
+) error {
+	if policy.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan *hedgedAttempt, policy.maxAttempts)
+	var started, done int
+	start := func() {
+		attempt := newHedgedAttempt(response, opts)
+		started++
+		go func() {
+			attempt.err = handleHTTPResponseToGRPCRequest(
+				invoker(ctx, fullMethod, request, attempt.response, cc, attempt.opts...),
+			)
+			results <- attempt
+		}()
+	}
+	commit := func(attempt *hedgedAttempt) error {
+		// Wait for the canceled attempts, which may still be reading the request.
+		cancel()
+		for ; done < started; done++ {
+			<-results
+		}
+		attempt.commit(response)
+		return attempt.err
+	}
+	start()
+	timer := time.NewTimer(l.Config.Hedging.HedgingDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if started < policy.maxAttempts {
+				start()
+				timer.Reset(l.Config.Hedging.HedgingDelay)
+			}
+		case attempt := <-results:
+			done++
+			if attempt.err == nil || !slices.Contains(l.Config.Hedging.NonFatalStatusCodes, status.Code(attempt.err)) {
+				return commit(attempt)
+			}
+			switch {
+			case started < policy.maxAttempts:
+				// Non-fatal failures send the next attempt without waiting for the hedging delay.
+				start()
+				timer.Reset(l.Config.Hedging.HedgingDelay)
+			case done == started:
+				return commit(attempt)
+			}
+		}
+	}
+}
+
+// hedgedAttempt is an attempt of a hedged call, with its own response and call option results.
+type hedgedAttempt struct {
+	response proto.Message
+	opts     []grpc.CallOption
+	commits  []func()
+	err      error
+}
+
+// newHedgedAttempt returns a new attempt of a hedged call.
+// Call options that write results to the caller are replaced, to only write the results of the committed attempt.
+func newHedgedAttempt(response proto.Message, opts []grpc.CallOption) *hedgedAttempt {
+	attempt := &hedgedAttempt{
+		response: response.ProtoReflect().New().Interface(),
+		opts:     make([]grpc.CallOption, 0, len(opts)),
+	}
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case grpc.HeaderCallOption:
+			var header metadata.MD
+			attempt.opts = append(attempt.opts, grpc.Header(&header))
+			attempt.commits = append(attempt.commits, func() { *opt.HeaderAddr = header })
+		case grpc.TrailerCallOption:
+			var trailer metadata.MD
+			attempt.opts = append(attempt.opts, grpc.Trailer(&trailer))
+			attempt.commits = append(attempt.commits, func() { *opt.TrailerAddr = trailer })
+		case grpc.PeerCallOption:
+			var p peer.Peer
+			attempt.opts = append(attempt.opts, grpc.Peer(&p))
+			attempt.commits = append(attempt.commits, func() { *opt.PeerAddr = p })
+		default:
+			attempt.opts = append(attempt.opts, opt)
+		}
+	}
+	return attempt
+}
+
+// commit writes the response and call option results of the attempt to the caller.
+func (a *hedgedAttempt) commit(response proto.Message) {
+	if a.err == nil {
+		proto.Reset(response)
+		proto.Merge(response, a.response)
+	}
+	for _, commit := range a.commits {
+		commit()
+	}
+}
@@ -0,0 +1,191 @@
+package cloudclient
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	testproto "github.com/grpc-ecosystem/go-grpc-middleware/testing/testproto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gotest.tools/v3/assert"
+)
+
+func TestMiddleware_hedging(t *testing.T) {
+	newConfig := func(hedgingDelay time.Duration) Config {
+		return Config{
+			Timeout: 10 * time.Second,
+			Hedging: HedgingConfig{
+				Enabled:             true,
+				MaxAttempts:         3,
+				HedgingDelay:        hedgingDelay,
+				NonFatalStatusCodes: []codes.Code{codes.Unavailable},
+			},
+		}
+	}
+
+	t.Run("sends parallel attempts", func(t *testing.T) {
+		canceled := make(chan struct{})
+		server, client := newHedgingTestClient(t, newConfig(50*time.Millisecond), func(
+			ctx context.Context,
+			attempt int,
+		) (*testproto.PingResponse, error) {
+			if attempt == 1 {
+				// The first attempt is slow, and canceled when the hedged attempt is committed.
+				<-ctx.Done()
+				close(canceled)
+				return nil, status.FromContextError(ctx.Err()).Err()
+			}
+			return &testproto.PingResponse{Value: "attempt " + strconv.Itoa(attempt)}, nil
+		})
+		var header metadata.MD
+		response, err := client.Ping(context.Background(), &testproto.PingRequest{}, grpc.Header(&header))
+		assert.NilError(t, err)
+		assert.Equal(t, "attempt 2", response.GetValue())
+		assert.DeepEqual(t, []string{"2"}, header.Get("attempt"))
+		assert.Equal(t, int32(2), server.attempts.Load())
+		assert.Equal(t, int32(2), server.maxInFlight.Load())
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the first attempt to be canceled")
+		}
+	})
+
+	t.Run("sends the next attempt immediately after a non-fatal failure", func(t *testing.T) {
+		server, client := newHedgingTestClient(t, newConfig(time.Minute), func(
+			_ context.Context,
+			attempt int,
+		) (*testproto.PingResponse, error) {
+			if attempt == 1 {
+				return nil, status.Error(codes.Unavailable, "unavailable")
+			}
+			return &testproto.PingResponse{Value: "attempt " + strconv.Itoa(attempt)}, nil
+		})
+		response, err := client.Ping(context.Background(), &testproto.PingRequest{})
+		assert.NilError(t, err)
+		assert.Equal(t, "attempt 2", response.GetValue())
+		assert.Equal(t, int32(2), server.attempts.Load())
+	})
+
+	t.Run("commits fatal failures", func(t *testing.T) {
+		server, client := newHedgingTestClient(t, newConfig(time.Minute), func(
+			context.Context,
+			int,
+		) (*testproto.PingResponse, error) {
+			return nil, status.Error(codes.InvalidArgument, "invalid")
+		})
+		_, err := client.Ping(context.Background(), &testproto.PingRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, int32(1), server.attempts.Load())
+	})
+
+	t.Run("stops after max attempts", func(t *testing.T) {
+		server, client := newHedgingTestClient(t, newConfig(0), func(
+			context.Context,
+			int,
+		) (*testproto.PingResponse, error) {
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		})
+		_, err := client.Ping(context.Background(), &testproto.PingRequest{})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, int32(3), server.attempts.Load())
+	})
+
+	t.Run("timeout applies to all attempts", func(t *testing.T) {
+		config := newConfig(10 * time.Millisecond)
+		config.Timeout = 100 * time.Millisecond
+		server, client := newHedgingTestClient(t, config, func(
+			ctx context.Context,
+			_ int,
+		) (*testproto.PingResponse, error) {
+			<-ctx.Done()
+			return nil, status.FromContextError(ctx.Err()).Err()
+		})
+		start := time.Now()
+		_, err := client.Ping(context.Background(), &testproto.PingRequest{})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.Assert(t, time.Since(start) < time.Second)
+		assert.Equal(t, int32(3), server.maxInFlight.Load())
+	})
+
+	t.Run("methods with a retry override are not hedged", func(t *testing.T) {
+		config := newConfig(0)
+		assert.NilError(t, config.Methods.Set("mwitkow.testproto.TestService/Ping:retry=false"))
+		server, client := newHedgingTestClient(t, config, func(
+			context.Context,
+			int,
+		) (*testproto.PingResponse, error) {
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		})
+		_, err := client.Ping(context.Background(), &testproto.PingRequest{})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, int32(1), server.attempts.Load())
+	})
+}
+
+// hedgingTestServer is a test service that counts attempts, and the max number of attempts in flight.
+type hedgingTestServer struct {
+	testproto.UnimplementedTestServiceServer
+	ping        func(ctx context.Context, attempt int) (*testproto.PingResponse, error)
+	mu          sync.Mutex
+	inFlight    int32
+	attempts    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+// Ping implements testproto.TestServiceServer.
+func (s *hedgingTestServer) Ping(ctx context.Context, _ *testproto.PingRequest) (*testproto.PingResponse, error) {
+	attempt := int(s.attempts.Add(1))
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight.Load() {
+		s.maxInFlight.Store(s.inFlight)
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+	if err := grpc.SetHeader(ctx, metadata.Pairs("attempt", strconv.Itoa(attempt))); err != nil {
+		return nil, err
+	}
+	return s.ping(ctx, attempt)
+}
+
+// newHedgingTestClient returns a client of a test server, on a client connection with the client middleware.
+func newHedgingTestClient(
+	t *testing.T,
+	config Config,
+	ping func(ctx context.Context, attempt int) (*testproto.PingResponse, error),
+) (*hedgingTestServer, testproto.TestServiceClient) {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	testServer := &hedgingTestServer{ping: ping}
+	testproto.RegisterTestServiceServer(server, testServer)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+	middleware := &Middleware{Config: config}
+	conn, err := grpc.NewClient(
+		"passthrough://bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(config.AsServiceConfigJSON()),
+		grpc.WithChainUnaryInterceptor(middleware.GRPCUnaryClientInterceptor),
+	)
+	assert.NilError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return testServer, testproto.NewTestServiceClient(conn)
+}
@@ -0,0 +1,185 @@
+package cloudclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/idtoken"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+)
+
+// HTTPTransport is an http.RoundTripper for calls to other Cloud Run services.
+//
+// Requests are authenticated with ID tokens, and idempotent requests are retried according to the retry config.
+// Only transport errors and responses with status 429, 502, 503 or 504 are retried, when Unavailable is a retryable
+// status code.
+// The timeout of the config applies to each request, including retries.
+type HTTPTransport struct {
+	// Base is the transport used to make requests. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// TokenSource provides ID tokens to authenticate requests with. Requests are not authenticated when nil.
+	TokenSource oauth2.TokenSource
+	// Config of the timeout and retry behavior.
+	Config Config
+}
+
+var _ http.RoundTripper = &HTTPTransport{}
+
+// NewHTTPTransport creates a new HTTPTransport for calls to the service at the audience, authenticated with the
+// default service account's Google ID tokens.
+func NewHTTPTransport(
+	ctx context.Context,
+	audience string,
+	config Config,
+	base http.RoundTripper,
+) (*HTTPTransport, error) {
+	tokenSource, err := idtoken.NewTokenSource(ctx, audience, option.WithAudiences(audience))
+	if err != nil {
+		return nil, fmt.Errorf("new HTTP transport %s: %w", audience, err)
+	}
+	return &HTTPTransport{Base: base, TokenSource: tokenSource, Config: config}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *HTTPTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	cancel := context.CancelFunc(func() {})
+	if t.Config.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.Config.Timeout)
+	}
+	response, err := t.roundTrip(ctx, request)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The timeout applies until the response body is closed.
+	response.Body = &cancelOnCloseBody{ReadCloser: response.Body, cancel: cancel}
+	return response, nil
+}
+
+func (t *HTTPTransport) roundTrip(ctx context.Context, request *http.Request) (*http.Response, error) {
+	maxAttempts := 1
+	if t.Config.Retry.Enabled && t.Config.Retry.MaxAttempts > 1 && isIdempotent(request) {
+		maxAttempts = t.Config.Retry.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		attemptRequest, err := t.attemptRequest(ctx, request, attempt)
+		if err != nil {
+			return nil, err
+		}
+		response, err := t.base().RoundTrip(attemptRequest)
+		if attempt >= maxAttempts || !t.isRetryable(ctx, response, err) {
+			return response, err
+		}
+		if response != nil {
+			// Drain the body to enable reuse of the connection.
+			_, _ = io.Copy(io.Discard, response.Body)
+			_ = response.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(t.backoff(attempt)):
+		}
+	}
+}
+
+// attemptRequest returns a copy of the request for an attempt, with a fresh body and an ID token.
+func (t *HTTPTransport) attemptRequest(ctx context.Context, request *http.Request, attempt int) (*http.Request, error) {
+	result := request.Clone(ctx)
+	if attempt > 1 && request.Body != nil && request.Body != http.NoBody {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("retry %s %s: %w", request.Method, request.URL, err)
+		}
+		result.Body = body
+	}
+	if t.TokenSource != nil {
+		token, err := t.TokenSource.Token()
+		if err != nil {
+			// Round trippers must close the request body, also on errors.
+			if result.Body != nil {
+				_ = result.Body.Close()
+			}
+			return nil, fmt.Errorf("%s %s: %w", request.Method, request.URL, err)
+		}
+		token.SetAuthHeader(result)
+	}
+	return result, nil
+}
+
+func (t *HTTPTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// isRetryable returns true if the error or response of an attempt is retryable.
+// Transport errors and responses with a retryable HTTP status are retried as Unavailable, other responses are never
+// retried.
+func (t *HTTPTransport) isRetryable(ctx context.Context, response *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err == nil && !isRetryableHTTPStatus(response.StatusCode) {
+		return false
+	}
+	return slices.Contains(t.Config.Retry.RetryableStatusCodes, codes.Unavailable)
+}
+
+// backoff returns the backoff before the next attempt, as random(0, min(initial*multiplier^(n-1), max)).
+func (t *HTTPTransport) backoff(attempt int) time.Duration {
+	backoff := float64(t.Config.Retry.InitialBackoff) * math.Pow(t.Config.Retry.BackoffMultiplier, float64(attempt-1))
+	if maxBackoff := float64(t.Config.Retry.MaxBackoff); maxBackoff > 0 && backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(backoff))) //nolint:gosec // jitter does not need secure randomness
+}
+
+// isIdempotent returns true if the request can be retried, which requires an idempotent method, or an
+// Idempotency-Key header, and a body that can be replayed.
+func isIdempotent(request *http.Request) bool {
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		return false
+	}
+	switch request.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return request.Header.Get("Idempotency-Key") != ""
+	}
+}
+
+// isRetryableHTTPStatus returns true if the HTTP status is transient, as the statuses mapped to Unavailable by gRPC.
+// See: https://github.com/grpc/grpc/blob/master/doc/http-grpc-status-mapping.md
+func isRetryableHTTPStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// cancelOnCloseBody cancels the context of a request when the response body is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
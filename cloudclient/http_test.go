@@ -0,0 +1,199 @@
+package cloudclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.einride.tech/cloudrunner/cloudconfig"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"gotest.tools/v3/assert"
+)
+
+func TestHTTPTransport(t *testing.T) {
+	newConfig := func() Config {
+		return Config{
+			Timeout: time.Second,
+			Retry: RetryConfig{
+				Enabled:              true,
+				InitialBackoff:       time.Millisecond,
+				MaxBackoff:           time.Millisecond,
+				MaxAttempts:          3,
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []codes.Code{codes.Unavailable},
+			},
+		}
+	}
+	newServer := func(t *testing.T, failures int32, attempts *atomic.Int32) *httptest.Server {
+		t.Helper()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if attempts.Add(1) <= failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = io.WriteString(w, r.Header.Get("Authorization")+" "+string(body))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	t.Run("retries idempotent requests", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := newServer(t, 2, &attempts)
+		client := &http.Client{Transport: &HTTPTransport{
+			TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+			Config:      newConfig(),
+		}}
+		request, err := http.NewRequestWithContext(
+			context.Background(), http.MethodPut, server.URL, strings.NewReader("body"),
+		)
+		assert.NilError(t, err)
+		response, err := client.Do(request)
+		assert.NilError(t, err)
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		assert.NilError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "Bearer token body", string(body))
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("does not retry non-idempotent requests", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := newServer(t, 2, &attempts)
+		client := &http.Client{Transport: &HTTPTransport{Config: newConfig()}}
+		response, err := client.Post(server.URL, "text/plain", strings.NewReader("body"))
+		assert.NilError(t, err)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("retries requests with idempotency key", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := newServer(t, 1, &attempts)
+		client := &http.Client{Transport: &HTTPTransport{Config: newConfig()}}
+		request, err := http.NewRequestWithContext(
+			context.Background(), http.MethodPost, server.URL, strings.NewReader("body"),
+		)
+		assert.NilError(t, err)
+		request.Header.Set("Idempotency-Key", "key")
+		response, err := client.Do(request)
+		assert.NilError(t, err)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("stops after max attempts", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := newServer(t, 10, &attempts)
+		client := &http.Client{Transport: &HTTPTransport{Config: newConfig()}}
+		response, err := client.Get(server.URL)
+		assert.NilError(t, err)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("does not retry other statuses with the default config", func(t *testing.T) {
+		var config Config
+		loader, err := cloudconfig.New("client", &config, cloudconfig.WithEnv(map[string]string{}))
+		assert.NilError(t, err)
+		assert.NilError(t, loader.Load())
+		assert.Assert(t, slices.Contains(config.Retry.RetryableStatusCodes, codes.Unknown))
+		for _, status := range []int{
+			http.StatusBadRequest,
+			http.StatusConflict,
+			http.StatusPreconditionFailed,
+			http.StatusUnprocessableEntity,
+			http.StatusInternalServerError,
+		} {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(status)
+			}))
+			t.Cleanup(server.Close)
+			client := &http.Client{Transport: &HTTPTransport{Config: config}}
+			response, err := client.Get(server.URL)
+			assert.NilError(t, err)
+			_ = response.Body.Close()
+			assert.Equal(t, status, response.StatusCode)
+			assert.Equal(t, int32(1), attempts.Load(), "status %d", status)
+		}
+	})
+
+	t.Run("retries transport errors", func(t *testing.T) {
+		var attempts atomic.Int32
+		client := &http.Client{Transport: &HTTPTransport{
+			Base: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+				attempts.Add(1)
+				return nil, errors.New("connection refused")
+			}),
+			Config: newConfig(),
+		}}
+		_, err := client.Get("http://example.com")
+		assert.ErrorContains(t, err, "connection refused")
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("closes the request body on token errors", func(t *testing.T) {
+		body := &closeRecordingBody{Reader: strings.NewReader("body")}
+		client := &http.Client{Transport: &HTTPTransport{
+			TokenSource: tokenSourceFunc(func() (*oauth2.Token, error) {
+				return nil, errors.New("no token")
+			}),
+			Config: newConfig(),
+		}}
+		request, err := http.NewRequestWithContext(context.Background(), http.MethodPut, "http://example.com", body)
+		assert.NilError(t, err)
+		_, err = client.Do(request)
+		assert.ErrorContains(t, err, "no token")
+		assert.Assert(t, body.closed.Load())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		t.Cleanup(server.Close)
+		config := newConfig()
+		config.Timeout = 10 * time.Millisecond
+		client := &http.Client{Transport: &HTTPTransport{Config: config}}
+		_, err := client.Get(server.URL)
+		assert.Assert(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+type tokenSourceFunc func() (*oauth2.Token, error)
+
+func (f tokenSourceFunc) Token() (*oauth2.Token, error) {
+	return f()
+}
+
+// closeRecordingBody is a request body that records if it has been closed.
+type closeRecordingBody struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (b *closeRecordingBody) Close() error {
+	b.closed.Store(true)
+	return nil
+}
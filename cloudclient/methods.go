@@ -0,0 +1,230 @@
+package cloudclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MethodConfig overrides the default timeout, retry and circuit breaker behavior for a gRPC service or method.
+type MethodConfig struct {
+	// Name of the service, as package.Service, or of the method, as package.Service/Method.
+	Name string
+	// Timeout overrides the default timeout, when set. Set to zero to disable.
+	Timeout *time.Duration
+	// Retry overrides if retries are enabled, when set.
+	Retry *bool
+	// MaxAttempts overrides the default max number of attempts, when set.
+	MaxAttempts *int
+	// CircuitBreaker overrides if the circuit breaker is enabled, when set.
+	CircuitBreaker *bool
+}
+
+// MethodConfigs are per-service and per-method overrides of the default timeout, retry and circuit breaker behavior.
+//
+// MethodConfigs are parsed from a semicolon-separated list of a service or method name followed by comma-separated
+// overrides, for example:
+//
+//	my.pkg.Svc/LongExport:timeout=120s,retry=false;my.pkg.OtherSvc:maxAttempts=3,circuitBreaker=true
+//
+// or from a JSON list, for example:
+//
+//	[{"name":"my.pkg.Svc/LongExport","timeout":"120s","retry":false}]
+type MethodConfigs []MethodConfig
+
+// methodOverrideJSON is the JSON format of a MethodConfig.
+type methodOverrideJSON struct {
+	Name           string `json:"name"`
+	Timeout        string `json:"timeout,omitempty"`
+	Retry          *bool  `json:"retry,omitempty"`
+	MaxAttempts    *int   `json:"maxAttempts,omitempty"`
+	CircuitBreaker *bool  `json:"circuitBreaker,omitempty"`
+}
+
+// Set implements the Setter interface of cloudconfig.
+func (m *MethodConfigs) Set(value string) error {
+	value = strings.TrimSpace(value)
+	var result MethodConfigs
+	if strings.HasPrefix(value, "[") {
+		var entries []methodOverrideJSON
+		if err := json.Unmarshal([]byte(value), &entries); err != nil {
+			return fmt.Errorf("parse method configs: %w", err)
+		}
+		for _, entry := range entries {
+			config := MethodConfig{
+				Name:           entry.Name,
+				Retry:          entry.Retry,
+				MaxAttempts:    entry.MaxAttempts,
+				CircuitBreaker: entry.CircuitBreaker,
+			}
+			if entry.Timeout != "" {
+				timeout, err := time.ParseDuration(entry.Timeout)
+				if err != nil {
+					return fmt.Errorf("parse method config %s: %w", entry.Name, err)
+				}
+				config.Timeout = &timeout
+			}
+			result = append(result, config)
+		}
+	} else {
+		for _, entry := range strings.Split(value, ";") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+			config, err := parseMethodConfig(strings.TrimSpace(entry))
+			if err != nil {
+				return err
+			}
+			result = append(result, config)
+		}
+	}
+	if err := result.validate(); err != nil {
+		return err
+	}
+	*m = result
+	return nil
+}
+
+func parseMethodConfig(entry string) (MethodConfig, error) {
+	name, overrides, _ := strings.Cut(entry, ":")
+	config := MethodConfig{Name: name}
+	if overrides == "" {
+		return config, nil
+	}
+	for _, override := range strings.Split(overrides, ",") {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return MethodConfig{}, fmt.Errorf("parse method config %s: expected key=value, got %q", name, override)
+		}
+		switch key {
+		case "timeout":
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return MethodConfig{}, fmt.Errorf("parse method config %s: %w", name, err)
+			}
+			config.Timeout = &timeout
+		case "retry":
+			retry, err := strconv.ParseBool(value)
+			if err != nil {
+				return MethodConfig{}, fmt.Errorf("parse method config %s: %w", name, err)
+			}
+			config.Retry = &retry
+		case "maxAttempts":
+			maxAttempts, err := strconv.Atoi(value)
+			if err != nil {
+				return MethodConfig{}, fmt.Errorf("parse method config %s: %w", name, err)
+			}
+			config.MaxAttempts = &maxAttempts
+		case "circuitBreaker":
+			circuitBreaker, err := strconv.ParseBool(value)
+			if err != nil {
+				return MethodConfig{}, fmt.Errorf("parse method config %s: %w", name, err)
+			}
+			config.CircuitBreaker = &circuitBreaker
+		default:
+			return MethodConfig{}, fmt.Errorf(
+				"parse method config %s: unknown key %q, expected timeout, retry, maxAttempts or circuitBreaker",
+				name,
+				key,
+			)
+		}
+	}
+	return config, nil
+}
+
+func (m MethodConfigs) validate() error {
+	names := make(map[string]struct{}, len(m))
+	for _, config := range m {
+		service, method, _ := strings.Cut(strings.TrimPrefix(config.Name, "/"), "/")
+		if service == "" || strings.Contains(method, "/") {
+			return fmt.Errorf("method config %q: expected name as package.Service or package.Service/Method", config.Name)
+		}
+		if _, ok := names[config.Name]; ok {
+			return fmt.Errorf("method config %s: duplicate name", config.Name)
+		}
+		names[config.Name] = struct{}{}
+		if config.Timeout != nil && *config.Timeout < 0 {
+			return fmt.Errorf("method config %s: negative timeout", config.Name)
+		}
+		if config.MaxAttempts != nil && *config.MaxAttempts < 2 {
+			return fmt.Errorf("method config %s: maxAttempts must be at least 2, use retry=false to disable", config.Name)
+		}
+	}
+	return nil
+}
+
+// String returns the method configs in the format they are parsed from.
+func (m MethodConfigs) String() string {
+	entries := make([]string, 0, len(m))
+	for _, config := range m {
+		var overrides []string
+		if config.Timeout != nil {
+			overrides = append(overrides, "timeout="+config.Timeout.String())
+		}
+		if config.Retry != nil {
+			overrides = append(overrides, "retry="+strconv.FormatBool(*config.Retry))
+		}
+		if config.MaxAttempts != nil {
+			overrides = append(overrides, "maxAttempts="+strconv.Itoa(*config.MaxAttempts))
+		}
+		if config.CircuitBreaker != nil {
+			overrides = append(overrides, "circuitBreaker="+strconv.FormatBool(*config.CircuitBreaker))
+		}
+		entries = append(entries, config.Name+":"+strings.Join(overrides, ","))
+	}
+	return strings.Join(entries, ";")
+}
+
+// LogValue implements [slog.LogValuer].
+func (m MethodConfigs) LogValue() slog.Value {
+	return slog.StringValue(m.String())
+}
+
+// serviceAndMethod returns the service and method of the method config name.
+func (m MethodConfig) serviceAndMethod() (service, method string) {
+	service, method, _ = strings.Cut(strings.TrimPrefix(m.Name, "/"), "/")
+	return service, method
+}
+
+// find returns the method config of a full method, as /package.Service/Method, when set.
+// A method config takes precedence over a service config, as in the gRPC service config.
+func (m MethodConfigs) find(fullMethod string) (MethodConfig, bool) {
+	var result MethodConfig
+	var found bool
+	for _, config := range m {
+		service, method := config.serviceAndMethod()
+		switch {
+		case method == "" && strings.HasPrefix(fullMethod, "/"+service+"/"):
+			if !found {
+				result, found = config, true
+			}
+		case "/"+service+"/"+method == fullMethod:
+			return config, true
+		}
+	}
+	return result, found
+}
+
+// circuitBreaker returns the circuit breaker override of a full method, as /package.Service/Method, when set.
+// A method config takes precedence over a service config.
+func (m MethodConfigs) circuitBreaker(fullMethod string) *bool {
+	var result *bool
+	for _, config := range m {
+		if config.CircuitBreaker == nil {
+			continue
+		}
+		service, method := config.serviceAndMethod()
+		switch {
+		case method == "" && strings.HasPrefix(fullMethod, "/"+service+"/"):
+			if result == nil {
+				result = config.CircuitBreaker
+			}
+		case "/"+service+"/"+method == fullMethod:
+			return config.CircuitBreaker
+		}
+	}
+	return result
+}
@@ -2,18 +2,32 @@ package cloudclient
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
 )
 
-type Middleware struct{}
+// Middleware provides standard middleware for gRPC clients.
+type Middleware struct {
+	// Config for the middleware.
+	Config Config
+
+	mu              sync.Mutex
+	circuitBreakers map[circuitBreakerKey]*circuitBreaker
+}
 
 // GRPCUnaryClientInterceptor adds standard middleware for gRPC clients.
+// Calls are hedged according to the hedging config, and the circuit breaker counts a hedged call once.
 func (l *Middleware) GRPCUnaryClientInterceptor(
 	ctx context.Context,
 	fullMethod string,
@@ -23,7 +37,91 @@ func (l *Middleware) GRPCUnaryClientInterceptor(
 	invoker grpc.UnaryInvoker,
 	opts ...grpc.CallOption,
 ) error {
-	return handleHTTPResponseToGRPCRequest(invoker(ctx, fullMethod, request, response, cc, opts...))
+	return l.withCircuitBreaker(ctx, cc, fullMethod, opts, func() error {
+		if policy, ok := l.Config.hedgingPolicy(fullMethod); ok {
+			if response, ok := response.(protoadapt.MessageV1); ok {
+				return l.invokeHedged(
+					ctx, policy, fullMethod, request, protoadapt.MessageV2Of(response), cc, invoker, opts,
+				)
+			}
+		}
+		return handleHTTPResponseToGRPCRequest(invoker(ctx, fullMethod, request, response, cc, opts...))
+	})
+}
+
+// GRPCStreamClientInterceptor adds standard middleware for gRPC client streams.
+func (l *Middleware) GRPCStreamClientInterceptor(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	fullMethod string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	// The circuit breaker records the final status of the stream.
+	record, err := l.admitCircuitBreaker(ctx, cc, fullMethod, opts)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := streamer(ctx, desc, cc, fullMethod, opts...)
+	if err = handleHTTPResponseToGRPCRequest(err); err != nil {
+		if record != nil {
+			record(err)
+		}
+		return nil, err
+	}
+	result := &clientStream{ClientStream: stream, desc: desc, record: record}
+	if record != nil {
+		// Streams abandoned by the caller before receiving the final status are recorded when the context is done.
+		stop := context.AfterFunc(ctx, func() {
+			result.finish(status.FromContextError(ctx.Err()).Err())
+		})
+		result.stopAfterFunc.Store(&stop)
+	}
+	return result, nil
+}
+
+// clientStream translates errors from HTTP responses to gRPC streams, and records the final status of the stream.
+type clientStream struct {
+	grpc.ClientStream
+	desc          *grpc.StreamDesc
+	record        func(error)
+	stopAfterFunc atomic.Pointer[func() bool]
+	once          sync.Once
+}
+
+// Header implements grpc.ClientStream.
+func (s *clientStream) Header() (metadata.MD, error) {
+	md, err := s.ClientStream.Header()
+	return md, handleHTTPResponseToGRPCRequest(err)
+}
+
+// RecvMsg implements grpc.ClientStream.
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := handleHTTPResponseToGRPCRequest(s.ClientStream.RecvMsg(m))
+	switch {
+	case errors.Is(err, io.EOF):
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	case !s.desc.ServerStreams:
+		// Streams without server streaming are done after the first response.
+		s.finish(nil)
+	}
+	return err
+}
+
+// finish records the final status of the stream, once.
+func (s *clientStream) finish(err error) {
+	if s.record == nil {
+		return
+	}
+	s.once.Do(func() {
+		if stop := s.stopAfterFunc.Load(); stop != nil {
+			(*stop)()
+		}
+		s.record(err)
+	})
 }
 
 func handleHTTPResponseToGRPCRequest(errInput error) error {
@@ -1,8 +1,11 @@
 package cloudclient
 
 import (
+	"context"
+	"io"
 	"testing"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"gotest.tools/v3/assert"
@@ -103,3 +106,55 @@ func TestHandleHTTPResponseToGRPCRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestMiddleware_GRPCStreamClientInterceptor(t *testing.T) {
+	forbidden := status.Error(
+		codes.Unknown,
+		"Forbidden: HTTP status code 403; transport: received the unexpected content-type \"text/html\"",
+	)
+	var middleware Middleware
+	t.Run("streamer error", func(t *testing.T) {
+		streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (
+			grpc.ClientStream, error,
+		) {
+			return nil, forbidden
+		}
+		_, err := middleware.GRPCStreamClientInterceptor(
+			context.Background(), &grpc.StreamDesc{}, nil, "/test.Service/Method", streamer,
+		)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+	t.Run("receive error", func(t *testing.T) {
+		streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (
+			grpc.ClientStream, error,
+		) {
+			return &recvErrorClientStream{err: forbidden}, nil
+		}
+		stream, err := middleware.GRPCStreamClientInterceptor(
+			context.Background(), &grpc.StreamDesc{}, nil, "/test.Service/Method", streamer,
+		)
+		assert.NilError(t, err)
+		assert.Equal(t, codes.PermissionDenied, status.Code(stream.RecvMsg(nil)))
+	})
+	t.Run("receive EOF", func(t *testing.T) {
+		streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (
+			grpc.ClientStream, error,
+		) {
+			return &recvErrorClientStream{err: io.EOF}, nil
+		}
+		stream, err := middleware.GRPCStreamClientInterceptor(
+			context.Background(), &grpc.StreamDesc{}, nil, "/test.Service/Method", streamer,
+		)
+		assert.NilError(t, err)
+		assert.Equal(t, io.EOF, stream.RecvMsg(nil))
+	})
+}
+
+type recvErrorClientStream struct {
+	grpc.ClientStream
+	err error
+}
+
+func (s *recvErrorClientStream) RecvMsg(interface{}) error {
+	return s.err
+}
@@ -0,0 +1,38 @@
+package cloudconfig
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// parseAliasTag parses an alias tag of comma-separated deprecated keys.
+func parseAliasTag(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	aliases := strings.Split(tag, ",")
+	for i, alias := range aliases {
+		aliases[i] = strings.ToUpper(strings.TrimSpace(alias))
+	}
+	return aliases
+}
+
+// LogDeprecatedKeys logs a warning for each config value that was loaded from a deprecated alias of its key.
+func (c *Config) LogDeprecatedKeys(ctx context.Context) {
+	for _, cs := range c.configSpecs {
+		for _, fs := range cs.fieldSpecs {
+			if fs.Alias == "" {
+				continue
+			}
+			slog.WarnContext(
+				ctx,
+				"deprecated config key",
+				slog.String("config", cs.name),
+				slog.String("key", fs.Alias),
+				slog.String("replacement", fs.Key),
+				slog.String("provenance", fs.Provenance),
+			)
+		}
+	}
+}
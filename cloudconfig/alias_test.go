@@ -0,0 +1,165 @@
+package cloudconfig
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConfig_alias(t *testing.T) {
+	t.Parallel()
+	type spec struct {
+		Renamed string `alias:"OLD_NAME, older_name" default:"default" file:"true"`
+	}
+	secretFile := writeFile(t, "secret", "from-file")
+	for _, tt := range []struct {
+		name               string
+		env                map[string]string
+		overrides          map[string]string
+		expected           string
+		expectedProvenance string
+		expectedAlias      string
+	}{
+		{
+			name:               "key",
+			env:                map[string]string{"RENAMED": "key"},
+			expected:           "key",
+			expectedProvenance: provenanceEnv,
+		},
+		{
+			name:               "alias",
+			env:                map[string]string{"OLD_NAME": "alias"},
+			expected:           "alias",
+			expectedProvenance: provenanceEnv + provenanceAliasSuffix,
+			expectedAlias:      "OLD_NAME",
+		},
+		{
+			name:               "key over alias",
+			env:                map[string]string{"RENAMED": "key", "OLD_NAME": "alias"},
+			expected:           "key",
+			expectedProvenance: provenanceEnv,
+		},
+		{
+			name:               "key file over alias",
+			env:                map[string]string{"RENAMED_FILE": secretFile, "OLD_NAME": "alias"},
+			expected:           "from-file",
+			expectedProvenance: provenanceEnv + provenanceFileSuffix,
+		},
+		{
+			name:               "earlier alias over later alias",
+			env:                map[string]string{"OLD_NAME": "old", "OLDER_NAME": "older"},
+			expected:           "old",
+			expectedProvenance: provenanceEnv + provenanceAliasSuffix,
+			expectedAlias:      "OLD_NAME",
+		},
+		{
+			name:               "later alias",
+			env:                map[string]string{"OLDER_NAME": "older"},
+			expected:           "older",
+			expectedProvenance: provenanceEnv + provenanceAliasSuffix,
+			expectedAlias:      "OLDER_NAME",
+		},
+		{
+			name:               "alias file",
+			env:                map[string]string{"OLD_NAME_FILE": secretFile},
+			expected:           "from-file",
+			expectedProvenance: provenanceEnv + provenanceAliasSuffix + provenanceFileSuffix,
+			expectedAlias:      "OLD_NAME",
+		},
+		{
+			name:               "alias override",
+			env:                map[string]string{},
+			overrides:          map[string]string{"OLD_NAME": "override"},
+			expected:           "override",
+			expectedProvenance: provenanceOverride + provenanceAliasSuffix,
+			expectedAlias:      "OLD_NAME",
+		},
+		{
+			// The key takes precedence over aliases in all sources.
+			name:               "key over alias override",
+			env:                map[string]string{"RENAMED": "key"},
+			overrides:          map[string]string{"OLD_NAME": "override"},
+			expected:           "key",
+			expectedProvenance: provenanceEnv,
+		},
+		{
+			name:               "default",
+			env:                map[string]string{},
+			expected:           "default",
+			expectedProvenance: provenanceDefault,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s spec
+			config, err := New("test", &s, WithEnv(tt.env), WithOverrides(tt.overrides))
+			assert.NilError(t, err)
+			assert.NilError(t, config.Load())
+			assert.Equal(t, tt.expected, s.Renamed)
+			fs := config.configSpecs[0].fieldSpecs[0]
+			assert.Equal(t, tt.expectedProvenance, fs.Provenance)
+			assert.Equal(t, tt.expectedAlias, fs.Alias)
+		})
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+		for _, env := range []map[string]string{
+			{"OLD_NAME": "alias"},
+			{"OLD_NAME_FILE": secretFile},
+			{"RENAMED": "key", "OLDER_NAME": "older"},
+		} {
+			var s spec
+			config, err := New("test", &s, WithEnv(env), WithStrict())
+			assert.NilError(t, err)
+			err = config.Load()
+			if _, ok := env["RENAMED"]; ok {
+				// Aliases are not in use when the key is set.
+				assert.NilError(t, err)
+				continue
+			}
+			assert.Error(t, err, "strict config: deprecated key OLD_NAME in use, replaced by RENAMED")
+		}
+	})
+}
+
+func TestParseAliasTag(t *testing.T) {
+	t.Parallel()
+	assert.Assert(t, parseAliasTag("") == nil)
+	assert.DeepEqual(t, []string{"OLD"}, parseAliasTag("old"))
+	assert.DeepEqual(t, []string{"OLD", "OLDER"}, parseAliasTag("old, OLDER"))
+}
+
+// TestConfig_LogDeprecatedKeys is not parallel, since it replaces the default logger.
+func TestConfig_LogDeprecatedKeys(t *testing.T) {
+	type spec struct {
+		Renamed string `alias:"OLD_NAME"`
+		Current string `alias:"PREVIOUS"`
+	}
+	var s spec
+	config, err := New("test", &s, WithEnv(map[string]string{"OLD_NAME": "alias", "CURRENT": "key"}))
+	assert.NilError(t, err)
+	assert.NilError(t, config.Load())
+	var b strings.Builder
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&b, nil)))
+	config.LogDeprecatedKeys(context.Background())
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	assert.Equal(t, 1, len(lines), b.String())
+	var record map[string]any
+	assert.NilError(t, json.Unmarshal([]byte(lines[0]), &record))
+	delete(record, "time")
+	assert.DeepEqual(t, map[string]any{
+		"level":       "WARN",
+		"msg":         "deprecated config key",
+		"config":      "test",
+		"key":         "OLD_NAME",
+		"replacement": "RENAMED",
+		"provenance":  provenanceEnv + provenanceAliasSuffix,
+	}, record)
+}
@@ -1,13 +1,20 @@
 package cloudconfig
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
-	"os"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 	"text/tabwriter"
 	"time"
 
+	"cloud.google.com/go/compute/metadata"
 	"go.opentelemetry.io/otel/codes"
 )
 
@@ -23,6 +30,7 @@ func New(name string, spec interface{}, options ...Option) (*Config, error) {
 			{name: name, spec: spec},
 		},
 		envPrefix: envPrefix,
+		onGCE:     metadata.OnGCE,
 	}
 	for _, option := range options {
 		option(&config)
@@ -42,7 +50,20 @@ type Config struct {
 	configSpecs                      []*configSpec
 	envPrefix                        string
 	yamlServiceSpecificationFilename string
+	yamlContainerName                string
 	optionalSecrets                  bool
+	env                              map[string]string
+	overrides                        map[string]string
+	secretResolver                   SecretResolver
+	reloadInterval                   time.Duration
+	dotEnvFilenames                  []string
+	additionalSources                []Source
+	sources                          []namedSource
+	yamlEnvs                         []env
+	yamlSecretFiles                  map[string]string
+	strict                           bool
+	profileValues                    map[string]map[string]string
+	onGCE                            func() bool
 }
 
 type configSpec struct {
@@ -53,26 +74,54 @@ type configSpec struct {
 
 // Load values into the config.
 func (c *Config) Load() error {
-	if c.yamlServiceSpecificationFilename != "" {
-		envs, err := getEnvFromYAMLServiceSpecificationFile(c.yamlServiceSpecificationFilename)
-		if err != nil {
-			return err
-		}
-		if err := validateEnvSecretTags(envs, c.configSpecs); err != nil {
-			return err
+	return c.LoadContext(context.Background())
+}
+
+// LoadContext loads values into the config, using the provided context to resolve secret references.
+func (c *Config) LoadContext(ctx context.Context) error {
+	if err := c.validateOverrides(); err != nil {
+		return err
+	}
+	if err := c.validateProfileValues(); err != nil {
+		return err
+	}
+	if err := c.loadSources(); err != nil {
+		return err
+	}
+	var errs []error
+	for _, cs := range c.configSpecs {
+		if err := c.process(ctx, cs.fieldSpecs); err != nil {
+			errs = append(errs, err)
+			continue
 		}
-		for _, e := range envs {
-			if err := os.Setenv(e.Name, e.Value); err != nil {
-				return err
-			}
+		// Validate hooks are only called when all fields of the spec have been loaded and are valid.
+		errs = append(errs, validateSpec(cs.name, reflect.ValueOf(cs.spec))...)
+	}
+	if c.strict {
+		errs = append(errs, c.validateStrict())
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) validateOverrides() error {
+	for key := range c.overrides {
+		if key != profileEnvKey && !c.hasKey(key) {
+			return fmt.Errorf("override %s: unknown config key", key)
 		}
 	}
+	return nil
+}
+
+// hasKey returns true if the key, or a deprecated alias of the key, is a key of a config field.
+func (c *Config) hasKey(key string) bool {
 	for _, cs := range c.configSpecs {
-		if err := c.process(cs.fieldSpecs); err != nil {
-			return err
+		for _, fs := range cs.fieldSpecs {
+			if fs.Key == key || slices.Contains(fs.Aliases, key) {
+				return true
+			}
 		}
 	}
-	return nil
+	return false
 }
 
 func validateEnvSecretTags(envs []env, configSpecs []*configSpec) error {
@@ -82,7 +131,7 @@ func validateEnvSecretTags(envs []env, configSpecs []*configSpec) error {
 		}
 		for _, spec := range configSpecs {
 			for _, f := range spec.fieldSpecs {
-				if f.Key == env.Name {
+				if f.Key == env.Name || slices.Contains(f.Aliases, env.Name) {
 					if !f.Secret {
 						return fmt.Errorf("field %s does not have the correct secret tag", f.Name)
 					}
@@ -94,19 +143,33 @@ func validateEnvSecretTags(envs []env, configSpecs []*configSpec) error {
 }
 
 // PrintUsage prints usage of the config to the provided io.Writer.
+// The source column shows the provenance of each value, as it would be loaded from the current environment.
 func (c *Config) PrintUsage(w io.Writer) {
+	if c.sources == nil {
+		// Best effort, provenance is resolved from the sources that can be loaded.
+		if err := c.loadSources(); err != nil {
+			_, _ = fmt.Fprintf(w, "unable to load config sources, the source column may be incomplete: %v\n\n", err)
+		}
+	}
 	tabs := tabwriter.NewWriter(w, 1, 0, 4, ' ', 0)
-	_, _ = fmt.Fprintf(tabs, "CONFIG\tENV\tTYPE\tDEFAULT\tON GCE\n")
+	_, _ = fmt.Fprintf(tabs, "CONFIG\tENV\tDEPRECATED\tTYPE\tDEFAULT\tON GCE\tPROFILES\tSOURCE\n")
 	for _, cs := range c.configSpecs {
 		for _, fs := range cs.fieldSpecs {
+			provenance := fs.Provenance
+			if provenance == "" {
+				_, provenance, _, _ = c.lookupValue(fs)
+			}
 			_, _ = fmt.Fprintf(
 				tabs,
-				"%v\t%v\t%v\t%v\t%v\n",
+				"%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
 				cs.name,
 				fs.Key,
+				strings.Join(fs.Aliases, ","),
 				fs.Value.Type(),
 				fs.Tags.Get("default"),
 				fs.Tags.Get("onGCE"),
+				fs.Tags.Get("profile"),
+				provenance,
 			)
 		}
 	}
@@ -114,10 +177,13 @@ func (c *Config) PrintUsage(w io.Writer) {
 }
 
 // LogValue implements [slog.LogValuer].
+// The values of each config spec are grouped by the name of the spec, together with their provenance.
 func (c *Config) LogValue() slog.Value {
 	attrs := make([]slog.Attr, 0, len(c.configSpecs))
 	for _, configSpec := range c.configSpecs {
-		attrs = append(attrs, slog.Any(configSpec.name, fieldSpecsValue(configSpec.fieldSpecs)))
+		specAttrs := fieldSpecsValue(configSpec.fieldSpecs).LogValue().Group()
+		specAttrs = append(specAttrs, slog.Any("provenance", provenanceValue(configSpec.fieldSpecs)))
+		attrs = append(attrs, slog.Attr{Key: configSpec.name, Value: slog.GroupValue(specAttrs...)})
 	}
 	return slog.GroupValue(attrs...)
 }
@@ -147,8 +213,64 @@ func (fsv fieldSpecsValue) LogValue() slog.Value {
 			}
 			attrs = append(attrs, slog.Any(fs.Key, logValue))
 		default:
-			attrs = append(attrs, slog.Any(fs.Key, fs.Value.Interface()))
+			attrs = append(attrs, slog.Any(fs.Key, fieldLogValue(fs.Value)))
 		}
 	}
 	return slog.GroupValue(attrs...)
 }
+
+// fieldLogValue returns the log value of a field, with URLs and regexps, and slices and maps of them, as strings.
+func fieldLogValue(field reflect.Value) any {
+	if field.CanAddr() {
+		// Fields of pointers to structs, such as *regexp.Regexp, are loaded into the struct.
+		if s, ok := stringValue(field.Addr().Interface()); ok {
+			return s
+		}
+	}
+	if s, ok := stringValue(field.Interface()); ok {
+		return s
+	}
+	switch field.Kind() {
+	case reflect.Slice:
+		if _, ok := stringValue(reflect.Zero(field.Type().Elem()).Interface()); ok {
+			result := make([]string, 0, field.Len())
+			for i := range field.Len() {
+				s, _ := stringValue(field.Index(i).Interface())
+				result = append(result, s)
+			}
+			return result
+		}
+	case reflect.Map:
+		if _, ok := stringValue(reflect.Zero(field.Type().Elem()).Interface()); ok {
+			result := make(map[string]string, field.Len())
+			iter := field.MapRange()
+			for iter.Next() {
+				s, _ := stringValue(iter.Value().Interface())
+				result[fmt.Sprint(iter.Key().Interface())] = s
+			}
+			return result
+		}
+	}
+	return field.Interface()
+}
+
+// stringValue returns the string form of values that are not logged as strings by default.
+// Passwords of URLs are redacted.
+func stringValue(value any) (string, bool) {
+	switch value := value.(type) {
+	case url.URL:
+		return value.Redacted(), true
+	case *url.URL:
+		if value == nil {
+			return "", true
+		}
+		return value.Redacted(), true
+	case *regexp.Regexp:
+		if value == nil {
+			return "", true
+		}
+		return value.String(), true
+	default:
+		return "", false
+	}
+}
@@ -1,16 +1,18 @@
 package cloudconfig
 
 import (
+	"context"
 	"encoding"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/compute/metadata"
 	"google.golang.org/grpc/codes"
 )
 
@@ -42,11 +44,19 @@ type Setter interface {
 
 // fieldSpec maintains information about the configuration variable.
 type fieldSpec struct {
-	Name   string
+	Name string
+	// Path of the field in the spec, as dot-separated field names of nested structs.
+	Path   string
 	Key    string
 	Secret bool
 	Value  reflect.Value
 	Tags   reflect.StructTag
+	// Aliases are deprecated keys of the field, from the alias tag.
+	Aliases []string
+	// Provenance of the loaded value.
+	Provenance string
+	// Alias is the deprecated key the value was loaded from, if any.
+	Alias string
 }
 
 func collectFieldSpecs(prefix string, spec interface{}) ([]fieldSpec, error) {
@@ -69,11 +79,13 @@ func collectFieldSpecs(prefix string, spec interface{}) ([]fieldSpec, error) {
 		}
 		for f.Kind() == reflect.Pointer {
 			if f.IsNil() {
-				if f.Type().Elem().Kind() != reflect.Struct {
-					// nil pointer to a non-struct: leave it alone
+				elem := reflect.New(f.Type().Elem()).Elem()
+				if elem.Kind() != reflect.Struct || isLeafStruct(elem) || decodesJSON(ftype.Tag) {
+					// nil pointer to a value loaded as a whole, such as *regexp.Regexp: leave it alone, it is
+					// allocated when a value is loaded
 					break
 				}
-				// nil pointer to struct: create a zero instance
+				// nil pointer to a config struct: create a zero instance to load field by field
 				f.Set(reflect.New(f.Type().Elem()))
 			}
 			f = f.Elem()
@@ -82,10 +94,14 @@ func collectFieldSpecs(prefix string, spec interface{}) ([]fieldSpec, error) {
 		_, secret := ftype.Tag.Lookup("secret")
 		info := fieldSpec{
 			Name:   ftype.Name,
+			Path:   ftype.Name,
 			Value:  f,
 			Secret: secret,
 			Tags:   ftype.Tag,
 		}
+		if _, err := parseProfileTag(ftype.Tag.Get("profile")); err != nil {
+			return nil, fmt.Errorf("invalid profile tag of field %s: %w", ftype.Name, err)
+		}
 		// Default to the field name as the env var name (will be upcased)
 		info.Key = info.Name
 		if prefix != "" {
@@ -95,8 +111,10 @@ func collectFieldSpecs(prefix string, spec interface{}) ([]fieldSpec, error) {
 			info.Key = strings.ToUpper(ftype.Tag.Get("env"))
 		}
 		info.Key = strings.ToUpper(info.Key)
+		info.Aliases = parseAliasTag(ftype.Tag.Get("alias"))
 		infos = append(infos, info)
-		if f.Kind() == reflect.Struct {
+		// Structs are loaded field by field, unless loaded from a single value or decoded from JSON.
+		if f.Kind() == reflect.Struct && !decodesJSON(ftype.Tag) {
 			if setterFrom(f) == nil && textUnmarshaler(f) == nil && binaryUnmarshaler(f) == nil {
 				innerPrefix := prefix
 				if !ftype.Anonymous {
@@ -107,6 +125,11 @@ func collectFieldSpecs(prefix string, spec interface{}) ([]fieldSpec, error) {
 				if err != nil {
 					return nil, err
 				}
+				if !ftype.Anonymous {
+					for j := range embeddedInfos {
+						embeddedInfos[j].Path = ftype.Name + "." + embeddedInfos[j].Path
+					}
+				}
 				infos = append(infos[:len(infos)-1], embeddedInfos...)
 				continue
 			}
@@ -115,41 +138,110 @@ func collectFieldSpecs(prefix string, spec interface{}) ([]fieldSpec, error) {
 	return infos, nil
 }
 
-func (c *Config) process(fieldSpecs []fieldSpec) error {
-	for _, info := range fieldSpecs {
-		value, ok := os.LookupEnv(info.Key)
-		def := info.Tags.Get("default")
-		if !ok && def != "" {
-			value = def
+func (c *Config) process(ctx context.Context, fieldSpecs []fieldSpec) error {
+	var errs []error
+	for i := range fieldSpecs {
+		info := &fieldSpecs[i]
+		value, provenance, alias, ok := c.lookupValue(*info)
+		info.Provenance, info.Alias = provenance, alias
+		if !ok {
+			optional := isTrue(info.Tags.Get("secret")) && c.optionalSecrets
+			if isTrue(info.Tags.Get("required")) && !optional {
+				key := info.Key
+				errs = append(errs, fmt.Errorf("required key %s missing value", key))
+			} else if !optional {
+				errs = append(errs, validateField(*info)...)
+			}
+			continue
 		}
-		if !ok && metadata.OnGCE() {
-			if onGCE := info.Tags.Get("onGCE"); onGCE != "" {
-				value = onGCE
-				def = onGCE
+		if ref, ok := secretRef(value); ok && resolvesSecretRefs(*info) {
+			resolved, err := c.resolveSecret(ctx, ref)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("resolve secret %s for key %s: %w", ref, info.Key, err))
+				continue
 			}
+			// Values resolved from secrets are always masked.
+			value, info.Secret = resolved, true
+			info.Provenance += provenanceSecretSuffix
 		}
-		if !ok && def == "" {
-			if isTrue(info.Tags.Get("required")) && (!isTrue(info.Tags.Get("secret")) || !c.optionalSecrets) {
-				key := info.Key
-				return fmt.Errorf("required key %s missing value", key)
+		if filename, ok := fileRef(value); ok && readsFiles(info.Tags) {
+			if ref, ok := c.yamlSecretFiles[filename]; ok {
+				// Files in secret volumes of the YAML service specification are resolved from their secrets.
+				resolved, err := c.resolveSecret(ctx, ref)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("resolve secret %s of file %s for key %s: %w", ref, filename, info.Key, err))
+					continue
+				}
+				value, info.Secret = resolved, true
+				info.Provenance += provenanceFileSuffix + provenanceSecretSuffix
+			} else {
+				data, err := os.ReadFile(filename)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("read file for key %s: %w", info.Key, err))
+					continue
+				}
+				value = strings.TrimSuffix(string(data), "\n")
+				info.Provenance += provenanceFileSuffix
 			}
-			continue
 		}
-		if err := processField(value, info.Value); err != nil {
-			return &parseError{
+		if err := processField(value, info.Value, info.Tags); err != nil {
+			parseErr := &parseError{
 				KeyName:   info.Key,
 				FieldName: info.Name,
 				TypeName:  info.Value.Type().String(),
 				Value:     value,
 				Err:       err,
 			}
+			if info.Secret {
+				parseErr.Value = "<secret>"
+				parseErr.Err = maskSecretValue(err, value)
+			}
+			errs = append(errs, parseErr)
+			continue
 		}
+		errs = append(errs, validateField(*info)...)
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-func processField(value string, field reflect.Value) error {
+// processField parses a value into a field.
+//
+// Pointers are dereferenced before the value is decoded, and allocated when nil, so that Setter and unmarshaler
+// implementations are called on the value pointed to. The tags of the field select how the value is decoded:
+// decode:"json" decodes the value as JSON, and sep and kvsep set the delimiters between elements of slices and maps,
+// and between keys and values of maps.
+func processField(value string, field reflect.Value, tags reflect.StructTag) error {
 	typ := field.Type()
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+		if field.IsNil() {
+			field.Set(reflect.New(typ))
+		}
+		field = field.Elem()
+	}
+	if decodesJSON(tags) || isJSONValue(field) {
+		if strings.TrimSpace(value) == "" {
+			field.SetZero()
+			return nil
+		}
+		return json.Unmarshal([]byte(value), field.Addr().Interface())
+	}
+	if isURL(field) {
+		u, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(*u))
+		return nil
+	}
+	if isTime(field) {
+		t, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
 	if setter := setterFrom(field); setter != nil {
 		return setter.Set(value)
 	}
@@ -159,13 +251,6 @@ func processField(value string, field reflect.Value) error {
 	if b := binaryUnmarshaler(field); b != nil {
 		return b.UnmarshalBinary([]byte(value))
 	}
-	if typ.Kind() == reflect.Pointer {
-		typ = typ.Elem()
-		if field.IsNil() {
-			field.Set(reflect.New(typ))
-		}
-		field = field.Elem()
-	}
 	switch typ.Kind() {
 	case reflect.String:
 		field.SetString(value)
@@ -218,10 +303,10 @@ func processField(value string, field reflect.Value) error {
 		if typ.Elem().Kind() == reflect.Uint8 {
 			sl = reflect.ValueOf([]byte(value))
 		} else if len(strings.TrimSpace(value)) != 0 {
-			vals := strings.Split(value, ",")
+			vals := strings.Split(value, separator(tags))
 			sl = reflect.MakeSlice(typ, len(vals), len(vals))
 			for i, val := range vals {
-				err := processField(val, sl.Index(i))
+				err := processField(val, sl.Index(i), "")
 				if err != nil {
 					return err
 				}
@@ -231,19 +316,20 @@ func processField(value string, field reflect.Value) error {
 	case reflect.Map:
 		mp := reflect.MakeMap(typ)
 		if len(strings.TrimSpace(value)) != 0 {
-			pairs := strings.Split(value, ",")
+			pairs := strings.Split(value, separator(tags))
 			for _, pair := range pairs {
-				kvpair := strings.Split(pair, ":")
-				if len(kvpair) != 2 {
+				// Keys can not contain the key-value separator, but values can.
+				key, val, ok := strings.Cut(pair, keyValueSeparator(tags))
+				if !ok {
 					return fmt.Errorf("invalid map item: %q", pair)
 				}
 				k := reflect.New(typ.Key()).Elem()
-				err := processField(kvpair[0], k)
+				err := processField(key, k, "")
 				if err != nil {
 					return err
 				}
 				v := reflect.New(typ.Elem()).Elem()
-				err = processField(kvpair[1], v)
+				err = processField(val, v, "")
 				if err != nil {
 					return err
 				}
@@ -293,6 +379,62 @@ func isGRPCCode(value reflect.Value) bool {
 		value.Type().Name() == "Code"
 }
 
+func isURL(value reflect.Value) bool {
+	return value.Type() == reflect.TypeOf(url.URL{})
+}
+
+func isTime(value reflect.Value) bool {
+	return value.Type() == reflect.TypeOf(time.Time{})
+}
+
+// decodesJSON returns true if a field is tagged to be decoded from JSON, as decode:"json".
+// The json tag is not used, since it configures how config structs are encoded rather than how values are loaded.
+func decodesJSON(tags reflect.StructTag) bool {
+	return tags.Get("decode") == "json"
+}
+
+// isJSONValue returns true for slices and maps of structs, which are decoded from JSON unless they implement
+// Setter or an unmarshaler interface.
+func isJSONValue(value reflect.Value) bool {
+	if isLeafStruct(value) {
+		return false
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		elem := value.Type().Elem()
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		return elem.Kind() == reflect.Struct && !isLeafStruct(reflect.New(elem).Elem())
+	default:
+		return false
+	}
+}
+
+// parseTime parses a time in RFC 3339 format, or a date in YYYY-MM-DD format.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// separator returns the separator between elements of slices and maps, from the sep tag.
+func separator(tags reflect.StructTag) string {
+	if sep := tags.Get("sep"); sep != "" {
+		return sep
+	}
+	return ","
+}
+
+// keyValueSeparator returns the separator between keys and values of maps, from the kvsep tag.
+func keyValueSeparator(tags reflect.StructTag) string {
+	if kvsep := tags.Get("kvsep"); kvsep != "" {
+		return kvsep
+	}
+	return ":"
+}
+
 func isDuration(value reflect.Value) bool {
 	return value.Kind() == reflect.Int64 &&
 		value.Type().PkgPath() == "time" &&
@@ -0,0 +1,266 @@
+package cloudconfig
+
+import (
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+type upperSetter string
+
+func (u *upperSetter) Set(value string) error {
+	*u = upperSetter(strings.ToUpper(value))
+	return nil
+}
+
+type prefixTextUnmarshaler struct {
+	value string
+}
+
+func (p *prefixTextUnmarshaler) UnmarshalText(text []byte) error {
+	p.value = "text:" + string(text)
+	return nil
+}
+
+func TestConfig_decoding(t *testing.T) {
+	t.Parallel()
+	type endpoint struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	type spec struct {
+		Name      string              `json:"name"` // the json tag does not decode values from JSON
+		Endpoint  endpoint            `decode:"json"`
+		Endpoints []endpoint          // slices of structs are decoded from JSON without a tag
+		ByName    map[string]endpoint // as are maps of structs
+		Labels    map[string]string   `decode:"json"`
+		Hosts     []string            `sep:";"`
+		Weights   map[string]int      `sep:";" kvsep:"="`
+		Upstreams map[string]string
+		URL       url.URL
+		Addr      netip.Addr
+		Pattern   *regexp.Regexp
+		Date      time.Time
+		Time      time.Time
+		Setter    *upperSetter
+		Text      *prefixTextUnmarshaler
+	}
+	var s spec
+	config, err := New("test", &s, WithEnv(map[string]string{
+		"NAME":      "foo",
+		"ENDPOINT":  `{"host":"localhost","port":8080}`,
+		"ENDPOINTS": `[{"host":"a","port":1},{"host":"b","port":2}]`,
+		"BYNAME":    `{"a":{"host":"a","port":1}}`,
+		"LABELS":    `{"team":"platform"}`,
+		"HOSTS":     "a,b;c",
+		"WEIGHTS":   "a=1;b=2",
+		"UPSTREAMS": "a:http://a:8080,b:http://b",
+		"URL":       "https://example.com/path",
+		"ADDR":      "10.0.0.1",
+		"PATTERN":   "^a+$",
+		"DATE":      "2024-01-02",
+		"TIME":      "2024-01-02T03:04:05.6Z",
+		"SETTER":    "value",
+		"TEXT":      "value",
+	}))
+	assert.NilError(t, err)
+	assert.NilError(t, config.Load())
+	assert.Equal(t, "foo", s.Name)
+	assert.DeepEqual(t, endpoint{Host: "localhost", Port: 8080}, s.Endpoint)
+	assert.DeepEqual(t, []endpoint{{Host: "a", Port: 1}, {Host: "b", Port: 2}}, s.Endpoints)
+	assert.DeepEqual(t, map[string]endpoint{"a": {Host: "a", Port: 1}}, s.ByName)
+	assert.DeepEqual(t, map[string]string{"team": "platform"}, s.Labels)
+	assert.DeepEqual(t, []string{"a,b", "c"}, s.Hosts)
+	assert.DeepEqual(t, map[string]int{"a": 1, "b": 2}, s.Weights)
+	// Values of map items can contain the key-value separator.
+	assert.DeepEqual(t, map[string]string{"a": "http://a:8080", "b": "http://b"}, s.Upstreams)
+	assert.Equal(t, "https://example.com/path", s.URL.String())
+	assert.Equal(t, netip.MustParseAddr("10.0.0.1"), s.Addr)
+	assert.Equal(t, "^a+$", s.Pattern.String())
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), s.Date)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 6e8, time.UTC), s.Time)
+	assert.Equal(t, upperSetter("VALUE"), *s.Setter)
+	assert.Equal(t, "text:value", s.Text.value)
+}
+
+func TestProcessField(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name          string
+		value         string
+		tags          reflect.StructTag
+		field         any
+		expected      any
+		expectedError string
+	}{
+		{name: "json", value: `{"a":1}`, tags: `decode:"json"`, field: new(map[string]int), expected: map[string]int{"a": 1}},
+		{name: "json empty", value: " ", tags: `decode:"json"`, field: &[]int{1}, expected: []int(nil)},
+		{name: "json invalid", value: "{", tags: `decode:"json"`, field: new([]int), expectedError: "unexpected end"},
+		{name: "json tag string", value: "foo", tags: `json:"name"`, field: new(string), expected: "foo"},
+		{name: "json tag slice", value: "1,2", tags: `json:"values"`, field: new([]int), expected: []int{1, 2}},
+		{name: "slice of structs empty", value: "", field: &[]struct{}{{}}, expected: []struct{}(nil)},
+		{name: "slice sep", value: "a|b", tags: `sep:"|"`, field: new([]string), expected: []string{"a", "b"}},
+		{name: "slice empty", value: "", field: &[]string{"a"}, expected: []string{}},
+		{name: "map", value: "a:1,b:2", field: new(map[string]int), expected: map[string]int{"a": 1, "b": 2}},
+		{name: "map cut", value: "a:b:c", field: new(map[string]string), expected: map[string]string{"a": "b:c"}},
+		{name: "map empty value", value: "a:", field: new(map[string]string), expected: map[string]string{"a": ""}},
+		{
+			name:     "map kvsep",
+			value:    "a=b:c",
+			tags:     `kvsep:"="`,
+			field:    new(map[string]string),
+			expected: map[string]string{"a": "b:c"},
+		},
+		{name: "map invalid item", value: "a:1,b", field: new(map[string]int), expectedError: `invalid map item: "b"`},
+		{name: "map invalid value", value: "a:b", field: new(map[string]int), expectedError: "invalid syntax"},
+		{name: "date", value: "2024-02-29", field: new(time.Time), expected: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{
+			name:     "rfc3339",
+			value:    "2024-02-29T12:00:00+01:00",
+			field:    new(time.Time),
+			expected: time.Date(2024, 2, 29, 11, 0, 0, 0, time.UTC),
+		},
+		{name: "time invalid", value: "2024-02-30", field: new(time.Time), expectedError: "cannot parse"},
+		{name: "url invalid", value: "http://[::1", field: new(url.URL), expectedError: "missing ']' in host"},
+		{name: "netip invalid", value: "10.0.0", field: new(netip.Addr), expectedError: "IPv4 address too short"},
+		{name: "regexp invalid", value: "(", field: new(regexp.Regexp), expectedError: "missing closing )"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			field := reflect.ValueOf(tt.field).Elem()
+			err := processField(tt.value, field, tt.tags)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NilError(t, err)
+			if actual, ok := field.Interface().(time.Time); ok {
+				assert.Assert(t, actual.Equal(tt.expected.(time.Time)), actual)
+				return
+			}
+			assert.DeepEqual(t, tt.expected, field.Interface())
+		})
+	}
+}
+
+func TestProcessField_pointers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil setter is allocated", func(t *testing.T) {
+		t.Parallel()
+		var s *upperSetter
+		assert.NilError(t, processField("value", reflect.ValueOf(&s).Elem(), ""))
+		assert.Equal(t, upperSetter("VALUE"), *s)
+	})
+
+	t.Run("setter is set through existing pointer", func(t *testing.T) {
+		t.Parallel()
+		existing := upperSetter("existing")
+		s := &existing
+		assert.NilError(t, processField("value", reflect.ValueOf(&s).Elem(), ""))
+		assert.Equal(t, &existing, s)
+		assert.Equal(t, upperSetter("VALUE"), existing)
+	})
+
+	t.Run("nil text unmarshaler is allocated", func(t *testing.T) {
+		t.Parallel()
+		var u *prefixTextUnmarshaler
+		assert.NilError(t, processField("value", reflect.ValueOf(&u).Elem(), ""))
+		assert.Equal(t, "text:value", u.value)
+	})
+
+	t.Run("nil url is allocated", func(t *testing.T) {
+		t.Parallel()
+		var u *url.URL
+		assert.NilError(t, processField("https://example.com", reflect.ValueOf(&u).Elem(), ""))
+		assert.Equal(t, "example.com", u.Host)
+	})
+
+	t.Run("nil int is allocated", func(t *testing.T) {
+		t.Parallel()
+		var i *int
+		assert.NilError(t, processField("42", reflect.ValueOf(&i).Elem(), ""))
+		assert.Equal(t, 42, *i)
+	})
+
+	t.Run("slice of pointers", func(t *testing.T) {
+		t.Parallel()
+		var s []*upperSetter
+		assert.NilError(t, processField("a,b", reflect.ValueOf(&s).Elem(), ""))
+		assert.Equal(t, 2, len(s))
+		assert.Equal(t, upperSetter("A"), *s[0])
+		assert.Equal(t, upperSetter("B"), *s[1])
+	})
+}
+
+func TestConfig_pointerFields(t *testing.T) {
+	t.Parallel()
+	type nested struct {
+		Value string `default:"default"`
+	}
+	type spec struct {
+		Pattern  *regexp.Regexp
+		URL      *url.URL
+		Time     *time.Time
+		Setter   *upperSetter
+		Endpoint *struct{ Host string } `decode:"json"`
+		Nested   *nested
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		t.Parallel()
+		var s spec
+		config, err := New("test", &s, WithEnv(map[string]string{}))
+		assert.NilError(t, err)
+		assert.NilError(t, config.Load())
+		// Pointers to values loaded as a whole are left nil without a value.
+		assert.Assert(t, s.Pattern == nil)
+		assert.Assert(t, s.URL == nil)
+		assert.Assert(t, s.Time == nil)
+		assert.Assert(t, s.Setter == nil)
+		assert.Assert(t, s.Endpoint == nil)
+		// Pointers to config structs are allocated and loaded field by field.
+		assert.DeepEqual(t, &nested{Value: "default"}, s.Nested)
+		var b strings.Builder
+		assert.NilError(t, config.PrintConfig(&b, "env"))
+		assert.Assert(t, strings.Contains(b.String(), "PATTERN=\n"), b.String())
+		assert.NilError(t, config.PrintUsageFormat(&b, "jsonschema"))
+	})
+
+	t.Run("set", func(t *testing.T) {
+		t.Parallel()
+		var s spec
+		config, err := New("test", &s, WithEnv(map[string]string{
+			"PATTERN":  "^a+$",
+			"URL":      "https://example.com",
+			"TIME":     "2024-01-02",
+			"SETTER":   "value",
+			"ENDPOINT": `{"Host":"localhost"}`,
+		}))
+		assert.NilError(t, err)
+		assert.NilError(t, config.Load())
+		assert.Assert(t, s.Pattern.MatchString("aa"))
+		assert.Equal(t, "example.com", s.URL.Host)
+		assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), *s.Time)
+		assert.Equal(t, upperSetter("VALUE"), *s.Setter)
+		assert.Equal(t, "localhost", s.Endpoint.Host)
+	})
+
+	t.Run("preset", func(t *testing.T) {
+		t.Parallel()
+		pattern := regexp.MustCompile("^b+$")
+		s := spec{Pattern: pattern}
+		config, err := New("test", &s, WithEnv(map[string]string{}))
+		assert.NilError(t, err)
+		assert.NilError(t, config.Load())
+		// Pointers set before loading are kept when there is no value.
+		assert.Equal(t, pattern, s.Pattern)
+		assert.Equal(t, "^b+$", s.Pattern.String())
+	})
+}
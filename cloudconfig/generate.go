@@ -0,0 +1,278 @@
+package cloudconfig
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WriteYAMLEnv writes a YAML env block for a Cloud Run container, generated from the config specs.
+//
+// Fields are included with their value on GCE, or their default value. Secret fields are included as secretKeyRef
+// placeholders, and required fields without a default are included with an empty value to be filled in.
+// Environment variables reserved by Cloud Run, such as PORT and K_SERVICE, are not included.
+func (c *Config) WriteYAMLEnv(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	root.Content = append(root.Content, scalarNode("env"), &yaml.Node{Kind: yaml.SequenceNode, Content: c.envNodes()})
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return fmt.Errorf("write YAML env: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("write YAML env: %w", err)
+	}
+	return nil
+}
+
+// MergeYAMLEnv merges the env generated from the config specs, as by WriteYAMLEnv, into a container of a
+// YAML Cloud Run service, job or worker pool specification file. The container is selected as when loading config
+// from the file, see WithYAMLContainerName.
+//
+// Existing env entries are kept as is, and generated entries missing from the file are appended to the env of the
+// container. The file is edited in place: all other bytes of the file, including comments and formatting, are kept.
+// Env in flow style, such as env: [{name: FOO, value: bar}], is not supported, except for an empty env: [].
+func (c *Config) MergeYAMLEnv(filename string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("merge YAML env into %s: %w", filename, err)
+		}
+	}()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return fmt.Errorf("empty YAML document")
+	}
+	container, err := containerNode(document.Content[0], c.yamlContainerName)
+	if err != nil {
+		return err
+	}
+	envNode := mappingValue(container, "env")
+	existing := map[string]bool{}
+	if envNode != nil {
+		for _, entry := range envNode.Content {
+			if name := mappingValue(entry, "name"); name != nil {
+				existing[name.Value] = true
+			}
+		}
+	}
+	var entries []*yaml.Node
+	for _, entry := range c.envNodes() {
+		if name := mappingValue(entry, "name"); !existing[name.Value] {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	switch {
+	case envNode == nil:
+		// Append an env key, with the generated entries, after the last line of the container.
+		indent := strings.Repeat(" ", container.Column-1)
+		text, err := encodeEnvEntries(entries, indent+"  ")
+		if err != nil {
+			return err
+		}
+		lines = insertLines(lines, lastLine(container), indent+"env:\n"+text)
+	case envNode.Kind == yaml.SequenceNode && len(envNode.Content) > 0 && envNode.Style&yaml.FlowStyle == 0:
+		// Append the generated entries after the last line of the last entry, with the indentation of the first.
+		text, err := encodeEnvEntries(entries, strings.Repeat(" ", envNode.Column-1))
+		if err != nil {
+			return err
+		}
+		lines = insertLines(lines, lastLine(envNode), text)
+	case envNode.Kind == yaml.SequenceNode && len(envNode.Content) == 0,
+		envNode.Kind == yaml.ScalarNode && envNode.Tag == "!!null":
+		// Replace an empty env, such as env: [] or env:, with the generated entries.
+		key := container.Content[slices.Index(container.Content, envNode)-1]
+		indent := strings.Repeat(" ", key.Column-1)
+		text, err := encodeEnvEntries(entries, indent+"  ")
+		if err != nil {
+			return err
+		}
+		line := lines[key.Line-1]
+		lines[key.Line-1] = line[:key.Column-1] + "env:" + lineEnding(line)
+		lines = insertLines(lines, key.Line, text)
+	default:
+		return fmt.Errorf("unsupported env at line %d: must be a block sequence", envNode.Line)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, []byte(strings.Join(lines, "")), info.Mode().Perm())
+}
+
+// encodeEnvEntries encodes env entries as a YAML block sequence, with each line indented by indent.
+func encodeEnvEntries(entries []*yaml.Node, indent string) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.SequenceNode, Content: entries}); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	var result strings.Builder
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line != "" {
+			result.WriteString(indent + line)
+		}
+	}
+	return result.String(), nil
+}
+
+// insertLines inserts text after the line with the 1-based line number.
+func insertLines(lines []string, line int, text string) []string {
+	if line > len(lines) {
+		line = len(lines)
+	}
+	if line > 0 && !strings.HasSuffix(lines[line-1], "\n") {
+		lines[line-1] += "\n"
+	}
+	return slices.Insert(lines, line, text)
+}
+
+// lastLine returns the 1-based number of the last line of a YAML node, including the lines of its descendants.
+func lastLine(node *yaml.Node) int {
+	line := node.Line
+	if node.Kind == yaml.ScalarNode && node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		// Block scalars begin on the line after the indicator.
+		line += strings.Count(strings.TrimSuffix(node.Value, "\n"), "\n") + 1
+	}
+	for _, child := range node.Content {
+		line = max(line, lastLine(child))
+	}
+	return line
+}
+
+func lineEnding(line string) string {
+	if strings.HasSuffix(line, "\n") {
+		return "\n"
+	}
+	return ""
+}
+
+// isReservedEnv returns true for environment variables that are set by Cloud Run, and can not be set in the env of
+// a container.
+func isReservedEnv(key string) bool {
+	switch key {
+	case "PORT", "K_SERVICE", "K_REVISION", "K_CONFIGURATION",
+		"CLOUD_RUN_JOB", "CLOUD_RUN_EXECUTION", "CLOUD_RUN_TASK_INDEX", "CLOUD_RUN_TASK_ATTEMPT", "CLOUD_RUN_TASK_COUNT":
+		return true
+	default:
+		return false
+	}
+}
+
+// envNodes returns the YAML env entries generated from the config specs.
+func (c *Config) envNodes() []*yaml.Node {
+	var result []*yaml.Node
+	for _, cs := range c.configSpecs {
+		for _, fs := range cs.fieldSpecs {
+			if isReservedEnv(fs.Key) {
+				continue
+			}
+			entry := &yaml.Node{Kind: yaml.MappingNode}
+			entry.Content = append(entry.Content, scalarNode("name"), scalarNode(fs.Key))
+			value, ok := fs.Tags.Lookup("onGCE")
+			if !ok {
+				value, ok = fs.Tags.Lookup("default")
+			}
+			switch {
+			case fs.Secret:
+				secretKeyRef := &yaml.Node{Kind: yaml.MappingNode}
+				secretKeyRef.Content = append(
+					secretKeyRef.Content,
+					scalarNode("key"), scalarNode("latest"),
+					scalarNode("name"), scalarNode(strings.ToLower(strings.ReplaceAll(fs.Key, "_", "-"))),
+				)
+				valueFrom := &yaml.Node{Kind: yaml.MappingNode}
+				valueFrom.Content = append(valueFrom.Content, scalarNode("secretKeyRef"), secretKeyRef)
+				entry.Content = append(entry.Content, scalarNode("valueFrom"), valueFrom)
+			case ok:
+				entry.Content = append(entry.Content, scalarNode("value"), stringNode(value))
+			case isTrue(fs.Tags.Get("required")):
+				entry.Content = append(entry.Content, scalarNode("value"), stringNode(""))
+			default:
+				continue
+			}
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// containerNode returns the selected container of a YAML Cloud Run service, job or worker pool specification.
+func containerNode(root *yaml.Node, containerName string) (*yaml.Node, error) {
+	kind := mappingValue(root, "kind")
+	if kind == nil {
+		return nil, fmt.Errorf("missing config kind")
+	}
+	var path []string
+	switch kind.Value {
+	case "Service", "WorkerPool": // Cloud Run Services and Worker Pools
+		path = []string{"spec", "template", "spec", "containers"}
+	case "Job": // Cloud Run Jobs
+		path = []string{"spec", "template", "spec", "template", "spec", "containers"}
+	default:
+		return nil, fmt.Errorf("unknown config kind: %s", kind.Value)
+	}
+	node := root
+	for _, key := range path {
+		if node = mappingValue(node, key); node == nil {
+			return nil, fmt.Errorf("missing %s", strings.Join(path, "."))
+		}
+	}
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("unexpected %s", strings.Join(path, "."))
+	}
+	names := make([]string, 0, len(node.Content))
+	for _, container := range node.Content {
+		var name string
+		if nameNode := mappingValue(container, "name"); nameNode != nil {
+			name = nameNode.Value
+		}
+		names = append(names, name)
+	}
+	i, err := selectContainer(names, containerName)
+	if err != nil {
+		return nil, err
+	}
+	return node.Content[i], nil
+}
+
+// mappingValue returns the value of a key in a YAML mapping node, or nil if the key is not present.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// stringNode returns a scalar node that is always encoded as a string, since Cloud Run env values are strings.
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
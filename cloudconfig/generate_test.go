@@ -0,0 +1,137 @@
+package cloudconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+type generateSpec struct {
+	Port   int `default:"8080"`
+	Logger struct {
+		Level string `default:"info"`
+	}
+	Timeout time.Duration `default:"10s"`
+	Enabled bool          `default:"false" onGCE:"true"`
+	Project string        `required:"true"`
+	APIKey  string        `secret:"true"`
+	Unset   string
+}
+
+func TestConfig_WriteYAMLEnv(t *testing.T) {
+	t.Parallel()
+	var s generateSpec
+	config, err := New("test", &s)
+	assert.NilError(t, err)
+	var b strings.Builder
+	assert.NilError(t, config.WriteYAMLEnv(&b))
+	const expected = `env:
+  - name: LOGGER_LEVEL
+    value: info
+  - name: TIMEOUT
+    value: 10s
+  - name: ENABLED
+    value: "true"
+  - name: PROJECT
+    value: ""
+  - name: APIKEY
+    valueFrom:
+      secretKeyRef:
+        key: latest
+        name: apikey
+`
+	assert.Equal(t, expected, b.String())
+}
+
+func TestConfig_MergeYAMLEnv(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name          string
+		containerName string
+	}{
+		{name: "service", containerName: "app"},
+		{name: "job"},
+		{name: "empty"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			input, err := os.ReadFile(filepath.Join("testdata", "generate", tt.name+".yaml"))
+			assert.NilError(t, err)
+			expected, err := os.ReadFile(filepath.Join("testdata", "generate", tt.name+".golden.yaml"))
+			assert.NilError(t, err)
+			filename := writeFile(t, tt.name+".yaml", string(input))
+			var s generateSpec
+			config, err := New("test", &s, WithYAMLContainerName(tt.containerName))
+			assert.NilError(t, err)
+			assert.NilError(t, config.MergeYAMLEnv(filename))
+			actual, err := os.ReadFile(filename)
+			assert.NilError(t, err)
+			assert.Equal(t, string(expected), string(actual))
+			// Merging is idempotent.
+			assert.NilError(t, config.MergeYAMLEnv(filename))
+			actual, err = os.ReadFile(filename)
+			assert.NilError(t, err)
+			assert.Equal(t, string(expected), string(actual))
+			// The merged file is loaded as config.
+			config, err = New(
+				"test",
+				&s,
+				WithEnv(map[string]string{"PROJECT": "my-project"}),
+				WithYAMLServiceSpecificationFile(filename),
+				WithYAMLContainerName(tt.containerName),
+				WithOptionalSecrets(),
+			)
+			assert.NilError(t, err)
+			assert.NilError(t, config.Load())
+			assert.Assert(t, s.Enabled)
+		})
+	}
+}
+
+func TestConfig_MergeYAMLEnv_errors(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name:          "empty",
+			content:       "",
+			expectedError: "empty YAML document",
+		},
+		{
+			name:          "unknown kind",
+			content:       "kind: Deployment\n",
+			expectedError: "unknown config kind: Deployment",
+		},
+		{
+			name:          "missing containers",
+			content:       "kind: Service\nspec: {}\n",
+			expectedError: "missing spec.template.spec.containers",
+		},
+		{
+			name: "flow env",
+			content: "kind: Service\nspec:\n  template:\n    spec:\n      containers:\n" +
+				"        - env: [{name: FOO, value: bar}]\n",
+			expectedError: "unsupported env at line 6: must be a block sequence",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			filename := writeFile(t, "service.yaml", tt.content)
+			var s generateSpec
+			config, err := New("test", &s)
+			assert.NilError(t, err)
+			assert.ErrorContains(t, config.MergeYAMLEnv(filename), tt.expectedError)
+			// The file is not modified on errors.
+			actual, err := os.ReadFile(filename)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.content, string(actual))
+		})
+	}
+}
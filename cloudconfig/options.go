@@ -1,5 +1,7 @@
 package cloudconfig
 
+import "time"
+
 // Option is a configuration option.
 type Option func(*Config)
 
@@ -27,9 +29,101 @@ func WithYAMLServiceSpecificationFile(filename string) Option {
 	}
 }
 
+// WithYAMLContainerName sets the name of the container in the YAML service specification file to load environment
+// variables from. Defaults to the container with the same name as the running binary, or else the first container.
+func WithYAMLContainerName(name string) Option {
+	return func(config *Config) {
+		config.yamlContainerName = name
+	}
+}
+
+// WithDotEnvFile adds a .env file to load values from.
+// Values in .env files take precedence over the YAML service specification file, but not over the environment.
+// When multiple .env files are added, values in earlier files take precedence.
+func WithDotEnvFile(filename string) Option {
+	return func(config *Config) {
+		config.dotEnvFilenames = append(config.dotEnvFilenames, filename)
+	}
+}
+
+// WithSource adds an additional source to load values from, with lower precedence than all other sources.
+// Only defaults have lower precedence than additional sources.
+func WithSource(source Source) Option {
+	return func(config *Config) {
+		config.additionalSources = append(config.additionalSources, source)
+	}
+}
+
+// WithEnv sets the environment to load values from, instead of the process environment.
+func WithEnv(env map[string]string) Option {
+	return func(config *Config) {
+		config.env = env
+	}
+}
+
+// WithOverrides sets config values, keyed by environment variable, that take precedence over the environment and
+// the YAML service specification file.
+func WithOverrides(overrides map[string]string) Option {
+	return func(config *Config) {
+		config.overrides = overrides
+	}
+}
+
+// WithStrict enables strict config loading, which fails when the YAML service specification, a .env file or the
+// environment contains variables under the env prefix that are not consumed by any config spec, when a value is
+// loaded from a deprecated alias, and when a required field has no value from a source.
+//
+// Without an env prefix, the process environment is not checked for unknown variables, since it also contains
+// variables that are not config, such as PATH and HOME.
+func WithStrict() Option {
+	return func(config *Config) {
+		config.strict = true
+	}
+}
+
+// WithProfileValues sets config values, keyed by environment variable, for a profile selected by CLOUDRUNNER_PROFILE.
+// Values take precedence over the profile tags of fields, which enables applications to define profile values for
+// fields of library config structs. Use the onGCE profile to set values for when running on GCE.
+func WithProfileValues(profile string, values map[string]string) Option {
+	return func(config *Config) {
+		if config.profileValues == nil {
+			config.profileValues = map[string]map[string]string{}
+		}
+		if config.profileValues[profile] == nil {
+			config.profileValues[profile] = map[string]string{}
+		}
+		for key, value := range values {
+			config.profileValues[profile][key] = value
+		}
+	}
+}
+
+// WithOnGCE sets whether config is loaded on GCE, which activates the onGCE profile, instead of detecting it from the
+// metadata server.
+func WithOnGCE(onGCE bool) Option {
+	return func(config *Config) {
+		config.onGCE = func() bool { return onGCE }
+	}
+}
+
 // WithOptionalSecrets overrides all secrets to be optional.
 func WithOptionalSecrets() Option {
 	return func(config *Config) {
 		config.optionalSecrets = true
 	}
 }
+
+// WithSecretResolver sets the SecretResolver used to resolve secret references in config values.
+// Defaults to resolving secret references from Secret Manager.
+func WithSecretResolver(resolver SecretResolver) Option {
+	return func(config *Config) {
+		config.secretResolver = resolver
+	}
+}
+
+// WithReloadInterval sets the interval between reloads of a config watched with Watch.
+func WithReloadInterval(interval time.Duration) Option {
+	return func(config *Config) {
+		config.reloadInterval = interval
+	}
+}
@@ -0,0 +1,168 @@
+package cloudconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PrintConfig prints the resolved config to the provided io.Writer, in the provided format.
+//
+// Supported formats are "env", "yaml" and "json". Secret values are masked, as in LogValue.
+func (c *Config) PrintConfig(w io.Writer, format string) error {
+	switch format {
+	case "env":
+		return c.printEnv(w)
+	case "yaml":
+		return c.printYAML(w)
+	case "json":
+		return c.printJSON(w)
+	default:
+		return fmt.Errorf("print config: unsupported format %q, expected env, yaml or json", format)
+	}
+}
+
+func (c *Config) printEnv(w io.Writer) error {
+	for _, cs := range c.configSpecs {
+		fieldSpecs := make(map[string]fieldSpec, len(cs.fieldSpecs))
+		for _, fs := range cs.fieldSpecs {
+			fieldSpecs[fs.Key] = fs
+		}
+		for _, attr := range fieldSpecsValue(cs.fieldSpecs).LogValue().Group() {
+			fs, ok := fieldSpecs[attr.Key]
+			if !ok {
+				return fmt.Errorf("print config: unknown key %s", attr.Key)
+			}
+			value, err := formatEnvValue(attr.Value, fs)
+			if err != nil {
+				return fmt.Errorf("print config: %w", err)
+			}
+			if value != "" && strings.ContainsAny(value, " \t\n\"'#$\\") {
+				value = strconv.Quote(value)
+			}
+			if _, err := fmt.Fprintf(w, "%s=%s\n", attr.Key, value); err != nil {
+				return fmt.Errorf("print config: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) printYAML(w io.Writer) error {
+	var root yaml.Node
+	root.Kind = yaml.MappingNode
+	for _, cs := range c.configSpecs {
+		var specNode yaml.Node
+		specNode.Kind = yaml.MappingNode
+		for _, attr := range fieldSpecsValue(cs.fieldSpecs).LogValue().Group() {
+			var valueNode yaml.Node
+			if err := valueNode.Encode(LogValueToAny(attr.Value)); err != nil {
+				return fmt.Errorf("print config: %w", err)
+			}
+			specNode.Content = append(specNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: attr.Key}, &valueNode)
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: cs.name}, &specNode)
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return fmt.Errorf("print config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("print config: %w", err)
+	}
+	return nil
+}
+
+func (c *Config) printJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	result := make(map[string]any, len(c.configSpecs))
+	for _, cs := range c.configSpecs {
+		result[cs.name] = LogValueToAny(fieldSpecsValue(cs.fieldSpecs).LogValue())
+	}
+	if err := encoder.Encode(result); err != nil {
+		return fmt.Errorf("print config: %w", err)
+	}
+	return nil
+}
+
+// LogValueToAny converts a [slog.Value], such as the LogValue of a Config, to a value that can be encoded as JSON
+// or YAML. Groups are converted to maps, and durations to strings.
+func LogValueToAny(value slog.Value) any {
+	value = value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		attrs := value.Group()
+		result := make(map[string]any, len(attrs))
+		for _, attr := range attrs {
+			result[attr.Key] = LogValueToAny(attr.Value)
+		}
+		return result
+	case slog.KindDuration:
+		return value.Duration().String()
+	default:
+		return value.Any()
+	}
+}
+
+// formatEnvValue formats a resolved [slog.Value] of a field in the format it is parsed from environment variables.
+func formatEnvValue(value slog.Value, fs fieldSpec) (string, error) {
+	value = value.Resolve()
+	if value.Kind() == slog.KindDuration {
+		return value.Duration().String(), nil
+	}
+	if fs.Secret {
+		return value.String(), nil
+	}
+	if decodesJSON(fs.Tags) || isJSONValue(fs.Value) {
+		data, err := json.Marshal(LogValueToAny(value))
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	switch v := value.Any().(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		// Setter implementations format values as they are parsed, like a [flag.Value].
+		if setterFrom(fs.Value) != nil {
+			return v.String(), nil
+		}
+	}
+	v := reflect.ValueOf(value.Any())
+	switch v.Kind() {
+	case reflect.Slice:
+		if b, ok := v.Interface().([]byte); ok {
+			return string(b), nil
+		}
+		values := make([]string, 0, v.Len())
+		for i := range v.Len() {
+			values = append(values, fmt.Sprint(v.Index(i).Interface()))
+		}
+		return strings.Join(values, separator(fs.Tags)), nil
+	case reflect.Map:
+		pairs := make([]string, 0, v.Len())
+		kvsep := keyValueSeparator(fs.Tags)
+		iter := v.MapRange()
+		for iter.Next() {
+			pairs = append(pairs, fmt.Sprint(iter.Key().Interface())+kvsep+fmt.Sprint(iter.Value().Interface()))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, separator(fs.Tags)), nil
+	case reflect.Invalid:
+		return "", nil
+	default:
+		return fmt.Sprint(value.Any()), nil
+	}
+}
@@ -0,0 +1,96 @@
+package cloudconfig
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConfig_PrintConfig(t *testing.T) {
+	t.Parallel()
+	type spec struct {
+		Name    string            `default:"foo bar"`
+		Timeout time.Duration     `default:"10s"`
+		Tags    []string          `default:"a,b"`
+		Labels  map[string]string `default:"k:v"`
+		Token   string            `default:"hunter2" secret:"true"`
+	}
+	var s spec
+	config, err := New("test", &s, WithEnv(map[string]string{}))
+	assert.NilError(t, err)
+	assert.NilError(t, config.Load())
+	for _, tt := range []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "env",
+			expected: `NAME="foo bar"
+TIMEOUT=10s
+TAGS=a,b
+LABELS=k:v
+TOKEN=<secret>
+`,
+		},
+		{
+			format: "json",
+			expected: `{
+  "test": {
+    "LABELS": {
+      "k": "v"
+    },
+    "NAME": "foo bar",
+    "TAGS": [
+      "a",
+      "b"
+    ],
+    "TIMEOUT": "10s",
+    "TOKEN": "<secret>"
+  }
+}
+`,
+		},
+		{
+			format: "yaml",
+			expected: `test:
+  NAME: foo bar
+  TIMEOUT: 10s
+  TAGS:
+    - a
+    - b
+  LABELS:
+    k: v
+  TOKEN: <secret>
+`,
+		},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+			var b strings.Builder
+			assert.NilError(t, config.PrintConfig(&b, tt.format))
+			assert.Equal(t, tt.expected, b.String())
+		})
+	}
+
+	t.Run("unsupported format", func(t *testing.T) {
+		t.Parallel()
+		var b strings.Builder
+		assert.ErrorContains(t, config.PrintConfig(&b, "xml"), "unsupported format")
+	})
+}
+
+func TestLogValueToAny(t *testing.T) {
+	t.Parallel()
+	value := slog.GroupValue(
+		slog.Group("group", slog.Duration("duration", time.Second), slog.Int("int", 1)),
+		slog.String("string", "value"),
+	)
+	expected := map[string]any{
+		"group":  map[string]any{"duration": "1s", "int": int64(1)},
+		"string": "value",
+	}
+	assert.DeepEqual(t, expected, LogValueToAny(value))
+}
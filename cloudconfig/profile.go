@@ -0,0 +1,111 @@
+package cloudconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// profileEnvKey is the environment variable that selects the active config profile.
+const profileEnvKey = "CLOUDRUNNER_PROFILE"
+
+// profileOnGCE is the built-in profile that is active when running on GCE, with values from the onGCE tag.
+const profileOnGCE = "onGCE"
+
+// profileNameRegexp matches profile names in profile tags.
+// Names start with a lowercase letter, to tell them apart from values containing = such as NOT_FOUND=WARN.
+//
+//nolint:gochecknoglobals // compiled regexp
+var profileNameRegexp = regexp.MustCompile(`^[a-z][a-zA-Z0-9-]*$`)
+
+// activeProfiles returns the active profiles, in order of precedence: the profile selected by CLOUDRUNNER_PROFILE,
+// and the built-in onGCE profile when running on GCE.
+func (c *Config) activeProfiles() []string {
+	var result []string
+	if profile, _, ok := c.lookupEnv(profileEnvKey); ok && profile != "" {
+		result = append(result, profile)
+	}
+	if len(result) == 0 || result[0] != profileOnGCE {
+		if c.onGCE() {
+			result = append(result, profileOnGCE)
+		}
+	}
+	return result
+}
+
+// lookupProfile looks up the value of a field in a profile.
+// Values set with WithProfileValues take precedence over the profile and onGCE tags of the field.
+func (c *Config) lookupProfile(info fieldSpec, profile string) (string, bool) {
+	if value, ok := c.profileValues[profile][info.Key]; ok {
+		return value, true
+	}
+	if profile == profileOnGCE {
+		if onGCE := info.Tags.Get("onGCE"); onGCE != "" {
+			return onGCE, true
+		}
+	}
+	value, ok := parseProfileValues(info)[profile]
+	return value, ok
+}
+
+// fieldProfileValues returns the values of a field in all profiles, from its profile tag and WithProfileValues.
+func (c *Config) fieldProfileValues(info fieldSpec) map[string]string {
+	result := parseProfileValues(info)
+	for profile, values := range c.profileValues {
+		if value, ok := values[info.Key]; ok {
+			if result == nil {
+				result = map[string]string{}
+			}
+			result[profile] = value
+		}
+	}
+	return result
+}
+
+// validateProfileValues validates that the values set with WithProfileValues are for known config keys.
+func (c *Config) validateProfileValues() error {
+	for profile, values := range c.profileValues {
+		if !profileNameRegexp.MatchString(profile) && profile != profileOnGCE {
+			return fmt.Errorf("profile values %s: invalid profile name", profile)
+		}
+		for key := range values {
+			if !c.hasKey(key) {
+				return fmt.Errorf("profile values %s: unknown config key %s", profile, key)
+			}
+		}
+	}
+	return nil
+}
+
+// provenanceProfile returns the provenance of values from a profile.
+func provenanceProfile(profile string) string {
+	if profile == profileOnGCE {
+		return provenanceOnGCE
+	}
+	return provenanceProfilePrefix + profile
+}
+
+// parseProfileTag parses a profile tag of the form name=value,name=value.
+// Values may contain commas, and a comma only starts a new profile when followed by a profile name and =.
+func parseProfileTag(tag string) (map[string]string, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	result := map[string]string{}
+	var name string
+	for _, part := range strings.Split(tag, ",") {
+		if key, value, ok := strings.Cut(part, "="); ok && profileNameRegexp.MatchString(key) {
+			if _, ok := result[key]; ok {
+				return nil, fmt.Errorf("duplicate profile %s", key)
+			}
+			name = key
+			result[name] = value
+			continue
+		}
+		if name == "" {
+			return nil, fmt.Errorf("expected name=value, got %q", part)
+		}
+		result[name] += "," + part
+	}
+	return result, nil
+}
@@ -0,0 +1,162 @@
+package cloudconfig
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConfig_profiles(t *testing.T) {
+	type spec struct {
+		Level  string `default:"info" profile:"dev=debug,staging=warn"`
+		Filter string `profile:"dev=A=1,B=2"`
+		Other  string `default:"default"`
+	}
+	for _, tt := range []struct {
+		name               string
+		options            []Option
+		expected           spec
+		expectedProvenance string
+		errorContains      string
+	}{
+		{
+			name:               "no profile",
+			options:            []Option{WithEnv(map[string]string{})},
+			expected:           spec{Level: "info", Other: "default"},
+			expectedProvenance: provenanceDefault,
+		},
+		{
+			name:               "dev profile",
+			options:            []Option{WithEnv(map[string]string{"CLOUDRUNNER_PROFILE": "dev"})},
+			expected:           spec{Level: "debug", Filter: "A=1,B=2", Other: "default"},
+			expectedProvenance: "profile:dev",
+		},
+		{
+			name: "env over profile",
+			options: []Option{
+				WithEnv(map[string]string{"CLOUDRUNNER_PROFILE": "staging", "LEVEL": "error"}),
+			},
+			expected:           spec{Level: "error", Other: "default"},
+			expectedProvenance: provenanceEnv,
+		},
+		{
+			name: "profile values over tags",
+			options: []Option{
+				WithEnv(map[string]string{"CLOUDRUNNER_PROFILE": "staging"}),
+				WithProfileValues("staging", map[string]string{"LEVEL": "info", "OTHER": "staging"}),
+			},
+			expected:           spec{Level: "info", Other: "staging"},
+			expectedProvenance: "profile:staging",
+		},
+		{
+			name: "profile values of inactive profile",
+			options: []Option{
+				WithEnv(map[string]string{"CLOUDRUNNER_PROFILE": "dev"}),
+				WithProfileValues("staging", map[string]string{"OTHER": "staging"}),
+			},
+			expected:           spec{Level: "debug", Filter: "A=1,B=2", Other: "default"},
+			expectedProvenance: "profile:dev",
+		},
+		{
+			name: "profile values of unknown key",
+			options: []Option{
+				WithEnv(map[string]string{}),
+				WithProfileValues("staging", map[string]string{"UNKNOWN": "value"}),
+			},
+			errorContains: "unknown config key UNKNOWN",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var s spec
+			config, err := New("test", &s, tt.options...)
+			assert.NilError(t, err)
+			err = config.Load()
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, s)
+			assert.Equal(t, tt.expectedProvenance, config.configSpecs[0].fieldSpecs[0].Provenance)
+		})
+	}
+}
+
+func TestParseProfileTag(t *testing.T) {
+	for _, tt := range []struct {
+		tag           string
+		expected      map[string]string
+		errorContains string
+	}{
+		{tag: "", expected: nil},
+		{tag: "dev=1,staging=0.1", expected: map[string]string{"dev": "1", "staging": "0.1"}},
+		{tag: "dev=a,b,prod=c", expected: map[string]string{"dev": "a,b", "prod": "c"}},
+		{tag: "dev=NOT_FOUND=WARN", expected: map[string]string{"dev": "NOT_FOUND=WARN"}},
+		{tag: "value", errorContains: "expected name=value"},
+		{tag: "dev=1,dev=2", errorContains: "duplicate profile dev"},
+	} {
+		t.Run(tt.tag, func(t *testing.T) {
+			actual, err := parseProfileTag(tt.tag)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestConfig_onGCE(t *testing.T) {
+	t.Parallel()
+	type spec struct {
+		Level string `default:"debug" onGCE:"info"`
+		Other string `default:"default"`
+	}
+	for _, tt := range []struct {
+		name               string
+		env                map[string]string
+		options            []Option
+		expected           spec
+		expectedProvenance string
+	}{
+		{
+			name:               "not on GCE",
+			env:                map[string]string{},
+			options:            []Option{WithOnGCE(false)},
+			expected:           spec{Level: "debug", Other: "default"},
+			expectedProvenance: provenanceDefault,
+		},
+		{
+			name:               "on GCE",
+			env:                map[string]string{},
+			options:            []Option{WithOnGCE(true)},
+			expected:           spec{Level: "info", Other: "default"},
+			expectedProvenance: provenanceOnGCE,
+		},
+		{
+			name:               "on GCE with profile values",
+			env:                map[string]string{},
+			options:            []Option{WithOnGCE(true), WithProfileValues("onGCE", map[string]string{"OTHER": "gce"})},
+			expected:           spec{Level: "info", Other: "gce"},
+			expectedProvenance: provenanceOnGCE,
+		},
+		{
+			name:               "profile over onGCE",
+			env:                map[string]string{"CLOUDRUNNER_PROFILE": "dev"},
+			options:            []Option{WithOnGCE(true), WithProfileValues("dev", map[string]string{"LEVEL": "warn"})},
+			expected:           spec{Level: "warn", Other: "default"},
+			expectedProvenance: "profile:dev",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s spec
+			config, err := New("test", &s, append([]Option{WithEnv(tt.env)}, tt.options...)...)
+			assert.NilError(t, err)
+			assert.NilError(t, config.Load())
+			assert.Equal(t, tt.expected, s)
+			assert.Equal(t, tt.expectedProvenance, config.configSpecs[0].fieldSpecs[0].Provenance)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package cloudconfig
+
+import (
+	"log/slog"
+)
+
+// Provenance of config values.
+const (
+	provenanceOverride      = "override"
+	provenanceEnv           = "env"
+	provenanceDotEnv        = "dotenv"
+	provenanceYAML          = "yaml"
+	provenanceSource        = "source"
+	provenanceSecretRef     = "secretRef"
+	provenanceOnGCE         = "onGCE"
+	provenanceProfilePrefix = "profile:"
+	provenanceDefault       = "default"
+	provenanceUnset         = "unset"
+	provenanceSecretSuffix  = "+secret"
+	provenanceFileSuffix    = "+file"
+	provenanceAliasSuffix   = "+alias"
+)
+
+// lookupValue looks up the raw value of a field, and its provenance.
+// When the value is loaded from a deprecated alias of the field's key, the alias is returned.
+// Returns false if the field has no value from any source, tag or default.
+func (c *Config) lookupValue(info fieldSpec) (value string, provenance string, alias string, ok bool) {
+	// The current key takes precedence over all aliases, and earlier aliases over later aliases.
+	for i, key := range append([]string{info.Key}, info.Aliases...) {
+		if i > 0 {
+			alias = key
+		}
+		if value, provenance, ok := c.lookupEnv(key); ok {
+			return value, provenance + aliasSuffix(alias), alias, true
+		}
+		if !readsFiles(info.Tags) {
+			continue
+		}
+		if filename, provenance, ok := c.lookupEnv(key + "_FILE"); ok {
+			return fileScheme + filename, provenance + aliasSuffix(alias), alias, true
+		}
+	}
+	if ref := info.Tags.Get("secretRef"); ref != "" {
+		return secretManagerScheme + ref, provenanceSecretRef, "", true
+	}
+	for _, profile := range c.activeProfiles() {
+		if value, ok := c.lookupProfile(info, profile); ok {
+			return value, provenanceProfile(profile), "", true
+		}
+	}
+	if def := info.Tags.Get("default"); def != "" {
+		return def, provenanceDefault, "", true
+	}
+	return "", provenanceUnset, "", false
+}
+
+func aliasSuffix(alias string) string {
+	if alias == "" {
+		return ""
+	}
+	return provenanceAliasSuffix
+}
+
+// provenanceValue is the provenance of the loaded values of a config spec.
+type provenanceValue []fieldSpec
+
+func (pv provenanceValue) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(pv))
+	for _, fs := range pv {
+		attrs = append(attrs, slog.String(fs.Key, fs.Provenance))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,85 @@
+package cloudconfig
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConfig_LogValue_provenance(t *testing.T) {
+	t.Parallel()
+	type spec struct {
+		Value   string `default:"default"`
+		Timeout string
+	}
+	type provenanceSpec struct {
+		Value string
+	}
+	var s spec
+	var p provenanceSpec
+	config, err := New(
+		"test",
+		&s,
+		WithAdditionalSpec("provenance", &p),
+		WithEnv(map[string]string{"TIMEOUT": "10s", "VALUE": "env"}),
+		WithOverrides(map[string]string{"VALUE": "override"}),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, config.Load())
+	// A spec named provenance does not collide with the provenance of values.
+	expected := map[string]any{
+		"test": map[string]any{
+			"VALUE":   "override",
+			"TIMEOUT": "10s",
+			"provenance": map[string]any{
+				"VALUE":   provenanceOverride,
+				"TIMEOUT": provenanceEnv,
+			},
+		},
+		"provenance": map[string]any{
+			"VALUE": "override",
+			"provenance": map[string]any{
+				"VALUE": provenanceOverride,
+			},
+		},
+	}
+	assert.DeepEqual(t, expected, LogValueToAny(config.LogValue()))
+}
+
+func TestConfig_PrintUsage(t *testing.T) {
+	t.Parallel()
+	type spec struct {
+		Value string `default:"default"`
+	}
+
+	t.Run("source", func(t *testing.T) {
+		t.Parallel()
+		var s spec
+		config, err := New("test", &s, WithEnv(map[string]string{"VALUE": "env"}))
+		assert.NilError(t, err)
+		var b strings.Builder
+		config.PrintUsage(&b)
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		assert.Equal(t, 2, len(lines))
+		assert.DeepEqual(t, []string{"test", "VALUE", "string", "default", provenanceEnv}, strings.Fields(lines[1]))
+	})
+
+	t.Run("sources error", func(t *testing.T) {
+		t.Parallel()
+		var s spec
+		config, err := New(
+			"test",
+			&s,
+			WithEnv(map[string]string{}),
+			WithYAMLServiceSpecificationFile(filepath.Join(t.TempDir(), "missing.yaml")),
+		)
+		assert.NilError(t, err)
+		var b strings.Builder
+		config.PrintUsage(&b)
+		assert.Assert(t, strings.HasPrefix(b.String(), "unable to load config sources"), b.String())
+		// Usage is still printed, with the provenance of values from the sources that could be loaded.
+		assert.Assert(t, strings.Contains(b.String(), "VALUE"))
+	})
+}
@@ -0,0 +1,366 @@
+package cloudconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// constraintTags are the validation tags of config fields.
+//
+//nolint:gochecknoglobals // constant list of tags
+var constraintTags = []string{"min", "max", "oneof", "pattern", "url", "nonempty"}
+
+// PrintUsageFormat prints usage of the config to the provided io.Writer, in the provided format.
+//
+// Supported formats are "text", as printed by PrintUsage, "json", "markdown" and "jsonschema". The JSON Schema
+// describes each config spec as an object with properties that follow the struct path of its fields, with nested
+// structs as nested objects. The env key of each field is given by its x-env property.
+func (c *Config) PrintUsageFormat(w io.Writer, format string) error {
+	switch format {
+	case "", "text":
+		c.PrintUsage(w)
+		return nil
+	case "json":
+		return c.printUsageJSON(w)
+	case "markdown":
+		return c.printUsageMarkdown(w)
+	case "jsonschema":
+		return c.printUsageJSONSchema(w)
+	default:
+		return fmt.Errorf("print usage: unsupported format %q, expected text, json, markdown or jsonschema", format)
+	}
+}
+
+// usageField is the usage of a config field.
+type usageField struct {
+	Path        string            `json:"path"`
+	Env         string            `json:"env"`
+	Aliases     []string          `json:"aliases,omitempty"`
+	Type        string            `json:"type"`
+	Default     string            `json:"default,omitempty"`
+	OnGCE       string            `json:"onGCE,omitempty"`
+	Profiles    map[string]string `json:"profiles,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Required    bool              `json:"required"`
+	Secret      bool              `json:"secret"`
+	Constraints map[string]string `json:"constraints,omitempty"`
+}
+
+// usageSpec is the usage of a config spec.
+type usageSpec struct {
+	Name   string       `json:"name"`
+	Fields []usageField `json:"fields"`
+}
+
+func (c *Config) usage() []usageSpec {
+	result := make([]usageSpec, 0, len(c.configSpecs))
+	for _, cs := range c.configSpecs {
+		spec := usageSpec{Name: cs.name, Fields: make([]usageField, 0, len(cs.fieldSpecs))}
+		for _, fs := range cs.fieldSpecs {
+			field := usageField{
+				Path:        fs.Path,
+				Env:         fs.Key,
+				Aliases:     fs.Aliases,
+				Type:        fs.Value.Type().String(),
+				Default:     fs.Tags.Get("default"),
+				OnGCE:       fs.Tags.Get("onGCE"),
+				Profiles:    c.fieldProfileValues(fs),
+				Description: fs.Tags.Get("desc"),
+				Required:    isTrue(fs.Tags.Get("required")),
+				Secret:      fs.Secret,
+			}
+			for _, tag := range constraintTags {
+				if value, ok := fs.Tags.Lookup(tag); ok {
+					if field.Constraints == nil {
+						field.Constraints = map[string]string{}
+					}
+					field.Constraints[tag] = value
+				}
+			}
+			spec.Fields = append(spec.Fields, field)
+		}
+		result = append(result, spec)
+	}
+	return result
+}
+
+func (c *Config) printUsageJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c.usage()); err != nil {
+		return fmt.Errorf("print usage: %w", err)
+	}
+	return nil
+}
+
+func (c *Config) printUsageMarkdown(w io.Writer) error {
+	var b strings.Builder
+	for _, spec := range c.usage() {
+		_, _ = fmt.Fprintf(&b, "### %s\n\n", spec.Name)
+		b.WriteString("| Env | Deprecated | Type | Default | On GCE | Profiles ")
+		b.WriteString("| Required | Secret | Constraints | Description |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |\n")
+		for _, field := range spec.Fields {
+			constraints := make([]string, 0, len(field.Constraints))
+			for _, tag := range constraintTags {
+				if value, ok := field.Constraints[tag]; ok {
+					constraints = append(constraints, tag+"="+value)
+				}
+			}
+			_, _ = fmt.Fprintf(
+				&b,
+				"| `%s` | %s | `%s` | %s | %s | %s | %t | %t | %s | %s |\n",
+				field.Env,
+				markdownCode(strings.Join(field.Aliases, "`, `")),
+				field.Type,
+				markdownCode(field.Default),
+				markdownCode(field.OnGCE),
+				markdownEscape(formatProfiles(field.Profiles)),
+				field.Required,
+				field.Secret,
+				markdownEscape(strings.Join(constraints, ", ")),
+				markdownEscape(field.Description),
+			)
+		}
+		b.WriteString("\n")
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("print usage: %w", err)
+	}
+	return nil
+}
+
+// parseProfileValues returns the values of a field in the profiles of its profile tag.
+func parseProfileValues(fs fieldSpec) map[string]string {
+	// Profile tags are validated when collecting field specs.
+	values, _ := parseProfileTag(fs.Tags.Get("profile"))
+	return values
+}
+
+// formatProfiles formats the values of a field in profiles, sorted by profile name.
+func formatProfiles(profiles map[string]string) string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		values = append(values, "`"+name+"="+profiles[name]+"`")
+	}
+	return strings.Join(values, ", ")
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func (c *Config) printUsageJSONSchema(w io.Writer) error {
+	properties := make(map[string]any, len(c.configSpecs))
+	for _, cs := range c.configSpecs {
+		specSchema := objectSchema()
+		for _, fs := range cs.fieldSpecs {
+			// Nested structs are objects with the fields of the struct as properties.
+			parent := specSchema
+			path := strings.Split(fs.Path, ".")
+			for _, name := range path[:len(path)-1] {
+				parentProperties := parent["properties"].(map[string]any)
+				child, ok := parentProperties[name].(map[string]any)
+				if !ok {
+					child = objectSchema()
+					parentProperties[name] = child
+				}
+				parent = child
+			}
+			name := path[len(path)-1]
+			parent["properties"].(map[string]any)[name] = c.fieldSchema(fs)
+			if isTrue(fs.Tags.Get("required")) {
+				required, _ := parent["required"].([]string)
+				parent["required"] = append(required, name)
+			}
+		}
+		properties[cs.name] = specSchema
+	}
+	schema := map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"type":       "object",
+		"properties": properties,
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return fmt.Errorf("print usage: %w", err)
+	}
+	return nil
+}
+
+// objectSchema returns the JSON Schema of a config spec or nested struct, without properties.
+func objectSchema() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{},
+		"additionalProperties": false,
+	}
+}
+
+// fieldSchema returns the JSON Schema of a config field.
+func (c *Config) fieldSchema(fs fieldSpec) map[string]any {
+	result := typeSchema(fs.Value)
+	result["x-env"] = fs.Key
+	if len(fs.Aliases) > 0 {
+		result["x-aliases"] = fs.Aliases
+	}
+	if desc := fs.Tags.Get("desc"); desc != "" {
+		result["description"] = desc
+	}
+	if def, ok := fs.Tags.Lookup("default"); ok {
+		result["default"] = schemaValue(result, def)
+	}
+	if onGCE, ok := fs.Tags.Lookup("onGCE"); ok {
+		result["x-onGCE"] = onGCE
+	}
+	if profiles := c.fieldProfileValues(fs); profiles != nil {
+		result["x-profiles"] = profiles
+	}
+	if fs.Secret {
+		result["writeOnly"] = true
+		result["x-secret"] = true
+	}
+	numeric := result["type"] == "integer" || result["type"] == "number"
+	if limit, ok := fs.Tags.Lookup("min"); ok {
+		setLimit(result, limit, numeric, "minimum", "minLength", "minItems")
+	}
+	if limit, ok := fs.Tags.Lookup("max"); ok {
+		setLimit(result, limit, numeric, "maximum", "maxLength", "maxItems")
+	}
+	target := result
+	if items, ok := result["items"].(map[string]any); ok {
+		target = items
+	}
+	if isTrue(fs.Tags.Get("nonempty")) {
+		switch result["type"] {
+		case "array":
+			result["minItems"] = 1
+		case "object":
+			result["minProperties"] = 1
+		default:
+			result["minLength"] = 1
+		}
+	}
+	if oneof, ok := fs.Tags.Lookup("oneof"); ok {
+		target["enum"] = strings.Split(oneof, ",")
+	}
+	if pattern, ok := fs.Tags.Lookup("pattern"); ok {
+		target["pattern"] = pattern
+	}
+	if isTrue(fs.Tags.Get("url")) {
+		target["format"] = "uri"
+	}
+	return result
+}
+
+// schemaValue converts a tag value to the JSON type of a schema.
+func schemaValue(schema map[string]any, value string) any {
+	switch schema["type"] {
+	case "integer":
+		if i, err := strconv.ParseInt(value, 0, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case "array":
+		return strings.Split(value, ",")
+	}
+	return value
+}
+
+func setLimit(schema map[string]any, limit string, numeric bool, numberKey, stringKey, arrayKey string) {
+	switch {
+	case numeric:
+		if value, err := strconv.ParseFloat(limit, 64); err == nil {
+			schema[numberKey] = value
+		}
+	case schema["x-format"] == "duration":
+		schema["x-"+numberKey] = limit
+	case schema["type"] == "string":
+		if value, err := strconv.Atoi(limit); err == nil {
+			schema[stringKey] = value
+		}
+	case schema["type"] == "array":
+		if value, err := strconv.Atoi(limit); err == nil {
+			schema[arrayKey] = value
+		}
+	}
+}
+
+// typeSchema returns the JSON Schema type of a config value, with values formatted as by PrintConfig.
+func typeSchema(value reflect.Value) map[string]any {
+	typ := value.Type()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	zero := reflect.New(typ).Elem()
+	if isDuration(zero) {
+		return map[string]any{"type": "string", "x-format": "duration"}
+	}
+	switch typ {
+	case reflect.TypeOf(url.URL{}):
+		return map[string]any{"type": "string", "format": "uri"}
+	case reflect.TypeOf(time.Time{}):
+		return map[string]any{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(regexp.Regexp{}):
+		return map[string]any{"type": "string", "format": "regex"}
+	case reflect.TypeOf(netip.Addr{}):
+		return map[string]any{"type": "string", "x-format": "ip"}
+	case reflect.TypeOf(netip.Prefix{}):
+		return map[string]any{"type": "string", "x-format": "cidr"}
+	}
+	if isGRPCCode(zero) || isLeafStruct(zero) {
+		return map[string]any{"type": "string"}
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string"}
+		}
+		return map[string]any{"type": "array", "items": typeSchema(reflect.New(typ.Elem()).Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(reflect.New(typ.Elem()).Elem())}
+	case reflect.Struct:
+		// Structs in slices and maps, and structs tagged decode:"json", are decoded from JSON.
+		return map[string]any{"type": "object"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
@@ -0,0 +1,129 @@
+package cloudconfig
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+type schemaSpec struct {
+	Name   string `required:"true" desc:"Name of the service"`
+	Server struct {
+		Timeout time.Duration `default:"10s" min:"1s"`
+		Retry   struct {
+			MaxAttempts int `default:"3" min:"1" max:"10"`
+		}
+	}
+	SchemaEmbedded
+}
+
+type SchemaEmbedded struct {
+	Level string `default:"info" oneof:"debug,info"`
+}
+
+func TestConfig_PrintUsageFormat_jsonschema(t *testing.T) {
+	t.Parallel()
+	var s schemaSpec
+	config, err := New("test", &s)
+	assert.NilError(t, err)
+	var b strings.Builder
+	assert.NilError(t, config.PrintUsageFormat(&b, "jsonschema"))
+	var actual map[string]any
+	assert.NilError(t, json.Unmarshal([]byte(b.String()), &actual))
+	expected := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type":    "object",
+		"properties": map[string]any{
+			"test": map[string]any{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []any{"Name"},
+				"properties": map[string]any{
+					"Name": map[string]any{
+						"type":        "string",
+						"description": "Name of the service",
+						"x-env":       "NAME",
+					},
+					"Server": map[string]any{
+						"type":                 "object",
+						"additionalProperties": false,
+						"properties": map[string]any{
+							"Timeout": map[string]any{
+								"type":      "string",
+								"x-format":  "duration",
+								"default":   "10s",
+								"x-minimum": "1s",
+								"x-env":     "SERVER_TIMEOUT",
+							},
+							"Retry": map[string]any{
+								"type":                 "object",
+								"additionalProperties": false,
+								"properties": map[string]any{
+									"MaxAttempts": map[string]any{
+										"type":    "integer",
+										"default": float64(3),
+										"minimum": float64(1),
+										"maximum": float64(10),
+										"x-env":   "SERVER_RETRY_MAXATTEMPTS",
+									},
+								},
+							},
+						},
+					},
+					// Fields of embedded structs are properties of the embedding struct.
+					"Level": map[string]any{
+						"type":    "string",
+						"default": "info",
+						"enum":    []any{"debug", "info"},
+						"x-env":   "LEVEL",
+					},
+				},
+			},
+		},
+	}
+	assert.DeepEqual(t, expected, actual)
+}
+
+func TestConfig_PrintUsageFormat(t *testing.T) {
+	t.Parallel()
+	var s schemaSpec
+	config, err := New("test", &s)
+	assert.NilError(t, err)
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		var b strings.Builder
+		assert.NilError(t, config.PrintUsageFormat(&b, "json"))
+		var actual []usageSpec
+		assert.NilError(t, json.Unmarshal([]byte(b.String()), &actual))
+		assert.Equal(t, 1, len(actual))
+		assert.Equal(t, "test", actual[0].Name)
+		assert.DeepEqual(t, usageField{
+			Path:        "Server.Retry.MaxAttempts",
+			Env:         "SERVER_RETRY_MAXATTEMPTS",
+			Type:        "int",
+			Default:     "3",
+			Constraints: map[string]string{"min": "1", "max": "10"},
+		}, actual[0].Fields[2])
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		t.Parallel()
+		var b strings.Builder
+		assert.NilError(t, config.PrintUsageFormat(&b, "markdown"))
+		assert.Assert(t, strings.HasPrefix(b.String(), "### test\n"))
+		assert.Assert(t, strings.Contains(
+			b.String(),
+			"| `LEVEL` |  | `string` | `info` |  |  | false | false | oneof=debug,info |  |\n",
+		), b.String())
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+		var b strings.Builder
+		assert.ErrorContains(t, config.PrintUsageFormat(&b, "xml"), "unsupported format")
+	})
+}
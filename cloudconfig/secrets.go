@@ -0,0 +1,121 @@
+package cloudconfig
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/api/option"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// secretManagerScheme is the URL scheme of config values that reference a Secret Manager secret version.
+const secretManagerScheme = "sm://"
+
+// SecretResolver resolves references to secrets into secret values.
+//
+// References are passed without the sm:// scheme, i.e. as projects/p/secrets/name/versions/latest.
+// Only references in values of fields tagged secret or secretRef are resolved.
+type SecretResolver interface {
+	ResolveSecret(ctx context.Context, ref string) (string, error)
+}
+
+// SecretManagerResolver resolves secret references by accessing secret versions in Secret Manager.
+type SecretManagerResolver struct {
+	service *secretmanager.Service
+}
+
+var _ SecretResolver = &SecretManagerResolver{}
+
+// NewSecretManagerResolver creates a new SecretResolver backed by Secret Manager.
+func NewSecretManagerResolver(ctx context.Context, opts ...option.ClientOption) (*SecretManagerResolver, error) {
+	service, err := secretmanager.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("new Secret Manager resolver: %w", err)
+	}
+	return &SecretManagerResolver{service: service}, nil
+}
+
+// ResolveSecret implements SecretResolver.
+// References to a secret without a version resolve the latest version of the secret.
+func (r *SecretManagerResolver) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	name := ref
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	response, err := r.service.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("access secret version %s: %w", name, err)
+	}
+	data, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("access secret version %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// MapSecretResolver resolves secret references from a map of references to secret values.
+// Useful for local development and tests.
+type MapSecretResolver map[string]string
+
+var _ SecretResolver = MapSecretResolver{}
+
+// NewFileSecretResolver creates a new MapSecretResolver from a YAML or JSON file mapping references to secret values.
+func NewFileSecretResolver(filename string) (MapSecretResolver, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("new file secret resolver: %w", err)
+	}
+	var result MapSecretResolver
+	if err := yaml.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("new file secret resolver %s: %w", filename, err)
+	}
+	return result, nil
+}
+
+// ResolveSecret implements SecretResolver.
+func (r MapSecretResolver) ResolveSecret(_ context.Context, ref string) (string, error) {
+	value, ok := r[ref]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", ref)
+	}
+	return value, nil
+}
+
+// secretRef returns the secret reference of a config value, if any.
+func secretRef(value string) (string, bool) {
+	return strings.CutPrefix(value, secretManagerScheme)
+}
+
+// resolvesSecretRefs returns true if secret references in values of a field are resolved, which is only the case for
+// fields tagged secret or secretRef. Values of other fields are loaded as is.
+func resolvesSecretRefs(info fieldSpec) bool {
+	_, secret := info.Tags.Lookup("secret")
+	_, ref := info.Tags.Lookup("secretRef")
+	return secret || ref
+}
+
+// maskSecretValue returns an error with the message of err, with all occurrences of a secret value masked.
+func maskSecretValue(err error, value string) error {
+	if value == "" {
+		return err
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), value, "<secret>"))
+}
+
+// resolveSecret resolves a secret reference with the configured SecretResolver.
+// Defaults to resolving secrets from Secret Manager.
+func (c *Config) resolveSecret(ctx context.Context, ref string) (string, error) {
+	if c.secretResolver == nil {
+		resolver, err := NewSecretManagerResolver(ctx)
+		if err != nil {
+			return "", err
+		}
+		c.secretResolver = resolver
+	}
+	return c.secretResolver.ResolveSecret(ctx, ref)
+}
@@ -0,0 +1,201 @@
+package cloudconfig
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/option"
+	"gotest.tools/v3/assert"
+)
+
+func TestConfig_secrets(t *testing.T) {
+	t.Parallel()
+	type spec struct {
+		Token  string `secret:"true"`
+		APIKey string `secretRef:"projects/p/secrets/api-key"`
+		Port   int    `secret:"true"`
+		Plain  string
+	}
+	resolver := MapSecretResolver{
+		"projects/p/secrets/token/versions/1": "token-value",
+		"projects/p/secrets/api-key":          "api-key-value",
+		"projects/p/secrets/port/versions/1":  "not-a-port",
+	}
+	for _, tt := range []struct {
+		name          string
+		env           map[string]string
+		options       []Option
+		expected      spec
+		expectedError string
+	}{
+		{
+			name:     "resolved",
+			env:      map[string]string{"TOKEN": "sm://projects/p/secrets/token/versions/1"},
+			expected: spec{Token: "token-value", APIKey: "api-key-value"},
+		},
+		{
+			name:     "not resolved for fields without secret tags",
+			env:      map[string]string{"PLAIN": "sm://projects/p/secrets/token/versions/1"},
+			expected: spec{APIKey: "api-key-value", Plain: "sm://projects/p/secrets/token/versions/1"},
+		},
+		{
+			name:     "value over secretRef",
+			env:      map[string]string{"APIKEY": "sm://projects/p/secrets/token/versions/1"},
+			expected: spec{APIKey: "token-value"},
+		},
+		{
+			name: "resolve failure",
+			env:  map[string]string{"TOKEN": "sm://projects/p/secrets/missing/versions/1"},
+			expectedError: "resolve secret projects/p/secrets/missing/versions/1 for key TOKEN: " +
+				"secret projects/p/secrets/missing/versions/1 not found",
+		},
+		{
+			// Secrets are optional when validating config with -validate, but references must still be resolvable.
+			name:    "resolve failure with optional secrets",
+			env:     map[string]string{"TOKEN": "sm://projects/p/secrets/missing/versions/1"},
+			options: []Option{WithOptionalSecrets()},
+			expectedError: "resolve secret projects/p/secrets/missing/versions/1 for key TOKEN: " +
+				"secret projects/p/secrets/missing/versions/1 not found",
+		},
+		{
+			name:          "resolved value is masked in parse errors",
+			env:           map[string]string{"PORT": "sm://projects/p/secrets/port/versions/1"},
+			expectedError: "converting '<secret>' to type int",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s spec
+			options := append([]Option{WithEnv(tt.env), WithSecretResolver(resolver)}, tt.options...)
+			config, err := New("test", &s, options...)
+			assert.NilError(t, err)
+			err = config.Load()
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.Assert(t, !strings.Contains(err.Error(), "not-a-port"))
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expected, s)
+		})
+	}
+
+	t.Run("resolved values are masked", func(t *testing.T) {
+		t.Parallel()
+		var s spec
+		config, err := New(
+			"test",
+			&s,
+			WithEnv(map[string]string{"TOKEN": "sm://projects/p/secrets/token/versions/1"}),
+			WithSecretResolver(resolver),
+		)
+		assert.NilError(t, err)
+		assert.NilError(t, config.Load())
+		values := LogValueToAny(config.LogValue()).(map[string]any)["test"].(map[string]any)
+		assert.Equal(t, "<secret>", values["TOKEN"])
+		assert.Equal(t, "<secret>", values["APIKEY"])
+		provenance := values["provenance"].(map[string]any)
+		assert.Equal(t, provenanceEnv+provenanceSecretSuffix, provenance["TOKEN"])
+		assert.Equal(t, provenanceSecretRef+provenanceSecretSuffix, provenance["APIKEY"])
+	})
+}
+
+func TestNewFileSecretResolver(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name          string
+		filename      string
+		content       string
+		expected      MapSecretResolver
+		expectedError string
+	}{
+		{
+			name:     "yaml",
+			filename: "secrets.yaml",
+			content:  "projects/p/secrets/token: token-value\nprojects/p/secrets/key/versions/1: key-value\n",
+			expected: MapSecretResolver{
+				"projects/p/secrets/token":          "token-value",
+				"projects/p/secrets/key/versions/1": "key-value",
+			},
+		},
+		{
+			name:     "json",
+			filename: "secrets.json",
+			content:  `{"projects/p/secrets/token": "token-value"}`,
+			expected: MapSecretResolver{"projects/p/secrets/token": "token-value"},
+		},
+		{
+			name:          "invalid",
+			filename:      "secrets.yaml",
+			content:       "- token-value\n",
+			expectedError: "new file secret resolver",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resolver, err := NewFileSecretResolver(writeFile(t, tt.filename, tt.content))
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expected, resolver)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewFileSecretResolver("testdata/missing.yaml")
+		assert.ErrorContains(t, err, "new file secret resolver")
+	})
+}
+
+func TestSecretManagerResolver_ResolveSecret(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/v1/"), ":access")
+		if !ok || !strings.HasPrefix(name, "projects/p/secrets/token/versions/") {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"name":    name,
+			"payload": map[string]any{"data": base64.StdEncoding.EncodeToString([]byte("value of " + name))},
+		})
+	}))
+	t.Cleanup(server.Close)
+	resolver, err := NewSecretManagerResolver(
+		context.Background(),
+		option.WithEndpoint(server.URL),
+		option.WithoutAuthentication(),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		ref           string
+		expected      string
+		expectedError string
+	}{
+		{ref: "projects/p/secrets/token", expected: "value of projects/p/secrets/token/versions/latest"},
+		{ref: "projects/p/secrets/token/versions/1", expected: "value of projects/p/secrets/token/versions/1"},
+		{
+			ref:           "projects/p/secrets/missing",
+			expectedError: "access secret version projects/p/secrets/missing/versions/latest",
+		},
+	} {
+		t.Run(tt.ref, func(t *testing.T) {
+			t.Parallel()
+			actual, err := resolver.ResolveSecret(context.Background(), tt.ref)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
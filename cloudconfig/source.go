@@ -0,0 +1,160 @@
+package cloudconfig
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Source is a source of config values, keyed by environment variable name.
+type Source interface {
+	// Lookup returns the value of the key, and true if the key is present in the source.
+	Lookup(key string) (string, bool)
+}
+
+// MapSource is a Source of config values from a map.
+type MapSource map[string]string
+
+var _ Source = MapSource{}
+
+// Lookup implements Source.
+func (s MapSource) Lookup(key string) (string, bool) {
+	value, ok := s[key]
+	return value, ok
+}
+
+// EnvSource is a Source of config values from the process environment.
+type EnvSource struct{}
+
+var _ Source = EnvSource{}
+
+// Lookup implements Source.
+func (EnvSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// NewDotEnvSource creates a new Source of config values from a .env file.
+//
+// Each line of the file is a KEY=VALUE pair, optionally prefixed with export. Blank lines and lines starting with #
+// are ignored. Values may be quoted: double-quoted values are unescaped, and single-quoted values are literal.
+func NewDotEnvSource(filename string) (MapSource, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("new .env source: %w", err)
+	}
+	result := MapSource{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("new .env source %s:%d: expected KEY=VALUE", filename, lineNumber)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("new .env source %s:%d: %w", filename, lineNumber, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		}
+		result[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("new .env source %s: %w", filename, err)
+	}
+	return result, nil
+}
+
+// NewYAMLServiceSpecificationSource creates a new Source of config values from the env of a container in a
+// YAML Cloud Run service, job or worker pool specification. The name of the service is provided as K_SERVICE.
+//
+// The container with the same name as the running binary is used, falling back to the first container.
+// References to other variables of the env, as $(VAR), are expanded.
+func NewYAMLServiceSpecificationSource(filename string) (MapSource, error) {
+	spec, err := getEnvFromYAMLServiceSpecificationFile(filename, "")
+	if err != nil {
+		return nil, err
+	}
+	return newYAMLServiceSpecificationSource(spec.serviceName, spec.envs), nil
+}
+
+func newYAMLServiceSpecificationSource(name string, envs []env) MapSource {
+	result := make(MapSource, len(envs)+1)
+	if name != "" {
+		result["K_SERVICE"] = name
+	}
+	for _, e := range envs {
+		result[e.Name] = e.Value
+	}
+	return result
+}
+
+// namedSource is a Source of config values with a name, used as the provenance of its values.
+type namedSource struct {
+	Source
+	name string
+}
+
+// loadSources loads the layered sources of config values, in order of precedence: overrides, the environment,
+// .env files, the YAML service specification file and additional sources. Defaults have the lowest precedence.
+func (c *Config) loadSources() error {
+	sources := make([]namedSource, 0, 4+len(c.dotEnvFilenames)+len(c.additionalSources))
+	if c.overrides != nil {
+		sources = append(sources, namedSource{Source: MapSource(c.overrides), name: provenanceOverride})
+	}
+	if c.env != nil {
+		sources = append(sources, namedSource{Source: MapSource(c.env), name: provenanceEnv})
+	} else {
+		sources = append(sources, namedSource{Source: EnvSource{}, name: provenanceEnv})
+	}
+	for _, filename := range c.dotEnvFilenames {
+		source, err := NewDotEnvSource(filename)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, namedSource{Source: source, name: provenanceDotEnv})
+	}
+	if c.yamlServiceSpecificationFilename != "" {
+		spec, err := getEnvFromYAMLServiceSpecificationFile(c.yamlServiceSpecificationFilename, c.yamlContainerName)
+		if err != nil {
+			return err
+		}
+		if err := validateEnvSecretTags(spec.envs, c.configSpecs); err != nil {
+			return err
+		}
+		c.yamlEnvs = spec.envs
+		c.yamlSecretFiles = spec.secretFiles
+		sources = append(sources, namedSource{
+			Source: newYAMLServiceSpecificationSource(spec.serviceName, spec.envs),
+			name:   provenanceYAML,
+		})
+	}
+	for _, source := range c.additionalSources {
+		sources = append(sources, namedSource{Source: source, name: provenanceSource})
+	}
+	c.sources = sources
+	return nil
+}
+
+// lookupEnv looks up the value of an environment variable in the layered sources, in order of precedence,
+// and returns the name of the source it was found in.
+func (c *Config) lookupEnv(key string) (value string, provenance string, ok bool) {
+	for _, source := range c.sources {
+		if value, ok := source.Lookup(key); ok {
+			return value, source.name, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,168 @@
+package cloudconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), name)
+	assert.NilError(t, os.WriteFile(filename, []byte(content), 0o600))
+	return filename
+}
+
+func writeServiceYAML(t *testing.T, name string, env map[string]string) string {
+	t.Helper()
+	content := "apiVersion: serving.knative.dev/v1\nkind: Service\nmetadata:\n  name: " + name + "\n" +
+		"spec:\n  template:\n    spec:\n      containers:\n        - name: app\n          env:\n"
+	for key, value := range env {
+		content += "            - name: " + key + "\n              value: " + value + "\n"
+	}
+	return writeFile(t, "service.yaml", content)
+}
+
+func TestConfig_sources(t *testing.T) {
+	type spec struct {
+		Service string `env:"K_SERVICE"`
+		Value   string `default:"default"`
+	}
+	yamlFile := writeServiceYAML(t, "yaml-service", map[string]string{"VALUE": "yaml"})
+	dotEnvFile := writeFile(t, ".env", "VALUE=dotenv\n")
+	for _, tt := range []struct {
+		name               string
+		options            []Option
+		expected           string
+		expectedProvenance string
+	}{
+		{
+			name:               "default",
+			options:            []Option{WithEnv(map[string]string{})},
+			expected:           "default",
+			expectedProvenance: provenanceDefault,
+		},
+		{
+			name:               "yaml",
+			options:            []Option{WithEnv(map[string]string{}), WithYAMLServiceSpecificationFile(yamlFile)},
+			expected:           "yaml",
+			expectedProvenance: provenanceYAML,
+		},
+		{
+			name: ".env over yaml",
+			options: []Option{
+				WithEnv(map[string]string{}),
+				WithYAMLServiceSpecificationFile(yamlFile),
+				WithDotEnvFile(dotEnvFile),
+			},
+			expected:           "dotenv",
+			expectedProvenance: provenanceDotEnv,
+		},
+		{
+			name: "env over .env",
+			options: []Option{
+				WithEnv(map[string]string{"VALUE": "env"}),
+				WithDotEnvFile(dotEnvFile),
+			},
+			expected:           "env",
+			expectedProvenance: provenanceEnv,
+		},
+		{
+			name: "override over env",
+			options: []Option{
+				WithEnv(map[string]string{"VALUE": "env"}),
+				WithOverrides(map[string]string{"VALUE": "override"}),
+			},
+			expected:           "override",
+			expectedProvenance: provenanceOverride,
+		},
+		{
+			name: "source under yaml",
+			options: []Option{
+				WithEnv(map[string]string{}),
+				WithSource(MapSource{"VALUE": "source"}),
+			},
+			expected:           "source",
+			expectedProvenance: provenanceSource,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var s spec
+			config, err := New("test", &s, tt.options...)
+			assert.NilError(t, err)
+			assert.NilError(t, config.Load())
+			assert.Equal(t, tt.expected, s.Value)
+			assert.Equal(t, tt.expectedProvenance, config.configSpecs[0].fieldSpecs[1].Provenance)
+		})
+	}
+}
+
+func TestConfig_noLeaksBetweenConfigs(t *testing.T) {
+	type spec struct {
+		Service string `env:"K_SERVICE"`
+		Value   string
+	}
+	_, hadService := os.LookupEnv("K_SERVICE")
+	_, hadValue := os.LookupEnv("VALUE")
+	var first, second spec
+	firstConfig, err := New(
+		"first",
+		&first,
+		WithEnv(map[string]string{}),
+		WithYAMLServiceSpecificationFile(writeServiceYAML(t, "first", map[string]string{"VALUE": "first"})),
+	)
+	assert.NilError(t, err)
+	secondConfig, err := New(
+		"second",
+		&second,
+		WithEnv(map[string]string{}),
+		WithDotEnvFile(writeFile(t, ".env", "VALUE=second\n")),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, firstConfig.Load())
+	assert.NilError(t, secondConfig.Load())
+	assert.Equal(t, spec{Service: "first", Value: "first"}, first)
+	assert.Equal(t, spec{Value: "second"}, second)
+	// Loading does not mutate the process environment.
+	_, hasService := os.LookupEnv("K_SERVICE")
+	_, hasValue := os.LookupEnv("VALUE")
+	assert.Equal(t, hadService, hasService)
+	assert.Equal(t, hadValue, hasValue)
+}
+
+func TestNewDotEnvSource(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		content       string
+		expected      MapSource
+		errorContains string
+	}{
+		{
+			name:     "values",
+			content:  "# comment\n\nA=1\nexport B = 2\nC=\"line\\nbreak\"\nD='$literal'\nE=a=b\n",
+			expected: MapSource{"A": "1", "B": "2", "C": "line\nbreak", "D": "$literal", "E": "a=b"},
+		},
+		{
+			name:          "missing separator",
+			content:       "A\n",
+			errorContains: ":1: expected KEY=VALUE",
+		},
+		{
+			name:          "invalid quoting",
+			content:       "A=1\nB=\"\\q\"\n",
+			errorContains: ":2:",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewDotEnvSource(writeFile(t, ".env", tt.content))
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expected, source)
+		})
+	}
+}
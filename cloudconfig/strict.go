@@ -0,0 +1,100 @@
+package cloudconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// validateStrict validates that all variables in the YAML service specification and .env files, and environment
+// variables under the env prefix, are consumed by a config spec, that no values are loaded from deprecated aliases,
+// and that all required fields have a value from a source rather than from tags.
+//
+// Without an env prefix, the process environment is not validated, since it contains variables of the entire
+// process and not only of the config.
+func (c *Config) validateStrict() error {
+	var errs []error
+	for _, e := range c.yamlEnvs {
+		if c.hasEnvPrefix(e.Name) && !c.consumesKey(e.Name) {
+			errs = append(errs, fmt.Errorf("strict config: unknown env %s in YAML service specification", e.Name))
+		}
+	}
+	for _, source := range c.sources {
+		switch {
+		case source.name == provenanceDotEnv:
+		case source.name == provenanceEnv && c.envPrefix != "":
+		default:
+			continue
+		}
+		for _, key := range sourceKeys(source.Source) {
+			if c.hasEnvPrefix(key) && !c.consumesKey(key) {
+				errs = append(errs, fmt.Errorf("strict config: unknown env %s in %s", key, source.name))
+			}
+		}
+	}
+	for _, cs := range c.configSpecs {
+		for _, fs := range cs.fieldSpecs {
+			if fs.Alias != "" {
+				errs = append(errs, fmt.Errorf("strict config: deprecated key %s in use, replaced by %s", fs.Alias, fs.Key))
+			}
+			if !isTrue(fs.Tags.Get("required")) {
+				continue
+			}
+			switch {
+			case fs.Provenance == provenanceDefault,
+				fs.Provenance == provenanceOnGCE,
+				fs.Provenance == provenanceSecretRef,
+				fs.Provenance == provenanceUnset,
+				fs.Provenance == "",
+				strings.HasPrefix(fs.Provenance, provenanceProfilePrefix):
+				errs = append(errs, fmt.Errorf("strict config: required key %s has no source", fs.Key))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hasEnvPrefix returns true if the environment variable is under the env prefix of the config.
+func (c *Config) hasEnvPrefix(key string) bool {
+	return c.envPrefix == "" || strings.HasPrefix(key, strings.ToUpper(c.envPrefix)+"_")
+}
+
+// consumesKey returns true if the environment variable is consumed by a config spec.
+func (c *Config) consumesKey(key string) bool {
+	if key == profileEnvKey || c.hasKey(key) {
+		return true
+	}
+	fileKey, ok := strings.CutSuffix(key, "_FILE")
+	if !ok {
+		return false
+	}
+	for _, cs := range c.configSpecs {
+		for _, fs := range cs.fieldSpecs {
+			if readsFiles(fs.Tags) && (fs.Key == fileKey || slices.Contains(fs.Aliases, fileKey)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sourceKeys returns the sorted keys of a source, when the source can be enumerated.
+func sourceKeys(source Source) []string {
+	var keys []string
+	switch source := source.(type) {
+	case MapSource:
+		for key := range source {
+			keys = append(keys, key)
+		}
+	case EnvSource:
+		for _, e := range os.Environ() {
+			key, _, _ := strings.Cut(e, "=")
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,102 @@
+package cloudconfig
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConfig_strict(t *testing.T) {
+	t.Parallel()
+	type spec struct {
+		Value    string `default:"default"`
+		Token    string `file:"true"`
+		Required string `required:"true" default:"default"`
+		Renamed  string `alias:"OLD_NAME"`
+	}
+	for _, tt := range []struct {
+		name          string
+		envPrefix     string
+		env           map[string]string
+		yamlEnv       map[string]string
+		dotEnv        string
+		expectedError string
+	}{
+		{
+			name: "valid",
+			env:  map[string]string{"REQUIRED": "value", "TOKEN_FILE": "/dev/null", "CLOUDRUNNER_PROFILE": "dev"},
+		},
+		{
+			name:          "required from default",
+			env:           map[string]string{},
+			expectedError: "strict config: required key REQUIRED has no source",
+		},
+		{
+			name:          "unknown in YAML",
+			env:           map[string]string{"REQUIRED": "value"},
+			yamlEnv:       map[string]string{"VALEU": "yaml"},
+			expectedError: "strict config: unknown env VALEU in YAML service specification",
+		},
+		{
+			name:          "unknown in .env",
+			env:           map[string]string{"REQUIRED": "value"},
+			dotEnv:        "VALEU=dotenv\n",
+			expectedError: "strict config: unknown env VALEU in dotenv",
+		},
+		{
+			name: "unknown in unprefixed env is not checked",
+			env:  map[string]string{"REQUIRED": "value", "VALEU": "env", "PATH": "/bin"},
+		},
+		{
+			name:          "unknown in prefixed env",
+			envPrefix:     "app",
+			env:           map[string]string{"APP_REQUIRED": "value", "APP_VALEU": "env", "PATH": "/bin"},
+			expectedError: "strict config: unknown env APP_VALEU in env",
+		},
+		{
+			name:      "YAML outside env prefix",
+			envPrefix: "app",
+			env:       map[string]string{"APP_REQUIRED": "value"},
+			yamlEnv:   map[string]string{"OTHER": "yaml"},
+		},
+		{
+			name:          "deprecated alias",
+			env:           map[string]string{"REQUIRED": "value", "OLD_NAME": "value"},
+			expectedError: "strict config: deprecated key OLD_NAME in use, replaced by RENAMED",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			options := []Option{WithStrict(), WithEnv(tt.env), WithEnvPrefix(tt.envPrefix)}
+			if tt.yamlEnv != nil {
+				options = append(options, WithYAMLServiceSpecificationFile(writeServiceYAML(t, "service", tt.yamlEnv)))
+			}
+			if tt.dotEnv != "" {
+				options = append(options, WithDotEnvFile(writeFile(t, ".env", tt.dotEnv)))
+			}
+			var s spec
+			config, err := New("test", &s, options...)
+			assert.NilError(t, err)
+			err = config.Load()
+			if tt.expectedError != "" {
+				assert.Error(t, err, tt.expectedError)
+			} else {
+				assert.NilError(t, err)
+			}
+		})
+	}
+
+	t.Run("not strict", func(t *testing.T) {
+		t.Parallel()
+		var s spec
+		config, err := New(
+			"test",
+			&s,
+			WithEnv(map[string]string{"OLD_NAME": "value"}),
+			WithDotEnvFile(writeFile(t, ".env", "VALEU=dotenv\n")),
+		)
+		assert.NilError(t, err)
+		assert.NilError(t, config.Load())
+		assert.Equal(t, "value", s.Renamed)
+	})
+}
@@ -0,0 +1,221 @@
+package cloudconfig
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validator is implemented by spec structs that validate their loaded values.
+//
+// Validate is called on the spec struct, and on every nested struct, after all fields have been loaded.
+type Validator interface {
+	Validate() error
+}
+
+// validateField validates the loaded value of a field against its validation tags.
+func validateField(info fieldSpec) []error {
+	field := info.Value
+	for field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+	var errs []error
+	fail := func(format string, args ...any) {
+		value := fmt.Sprint(field.Interface())
+		if info.Secret {
+			value = "<secret>"
+		}
+		errs = append(errs, &validationError{
+			KeyName:   info.Key,
+			FieldName: info.Name,
+			Value:     value,
+			Reason:    fmt.Sprintf(format, args...),
+		})
+	}
+	if limit, ok := info.Tags.Lookup("min"); ok {
+		if cmp, err := compareLimit(field, limit); err != nil {
+			fail("invalid min tag: %v", err)
+		} else if cmp < 0 {
+			fail("must be at least %s", limit)
+		}
+	}
+	if limit, ok := info.Tags.Lookup("max"); ok {
+		if cmp, err := compareLimit(field, limit); err != nil {
+			fail("invalid max tag: %v", err)
+		} else if cmp > 0 {
+			fail("must be at most %s", limit)
+		}
+	}
+	if isTrue(info.Tags.Get("nonempty")) {
+		switch field.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map:
+			if field.Len() == 0 {
+				fail("must not be empty")
+			}
+		default:
+			fail("invalid nonempty tag: unsupported type %s", field.Type())
+		}
+	}
+	if oneof, ok := info.Tags.Lookup("oneof"); ok {
+		allowed := strings.Split(oneof, ",")
+		for _, value := range elementStrings(field) {
+			if !slices.Contains(allowed, value) {
+				fail("must be one of [%s]", oneof)
+				break
+			}
+		}
+	}
+	if pattern, ok := info.Tags.Lookup("pattern"); ok {
+		if re, err := regexp.Compile(pattern); err != nil {
+			fail("invalid pattern tag: %v", err)
+		} else {
+			for _, value := range elementStrings(field) {
+				if !re.MatchString(value) {
+					fail("must match pattern %s", pattern)
+					break
+				}
+			}
+		}
+	}
+	if isTrue(info.Tags.Get("url")) {
+		for _, value := range elementStrings(field) {
+			if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+				fail("must be an absolute URL")
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// compareLimit compares the value of a field to a limit, returning -1, 0 or 1.
+// Numbers are compared by value, and strings, slices and maps by length.
+func compareLimit(field reflect.Value, limit string) (int, error) {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var l int64
+		var err error
+		if isDuration(field) {
+			var d time.Duration
+			d, err = time.ParseDuration(limit)
+			l = int64(d)
+		} else {
+			l, err = strconv.ParseInt(limit, 0, 64)
+		}
+		if err != nil {
+			return 0, err
+		}
+		return compare(field.Int(), l), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		l, err := strconv.ParseUint(limit, 0, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(field.Uint(), l), nil
+	case reflect.Float32, reflect.Float64:
+		l, err := strconv.ParseFloat(limit, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(field.Float(), l), nil
+	case reflect.String, reflect.Slice, reflect.Map:
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return 0, err
+		}
+		return compare(field.Len(), l), nil
+	default:
+		return 0, fmt.Errorf("unsupported type %s", field.Type())
+	}
+}
+
+func compare[T int | int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// elementStrings returns the non-empty string values of a field, or of its elements when the field is a slice.
+// Empty values are validated by the nonempty tag only.
+func elementStrings(field reflect.Value) []string {
+	values := []reflect.Value{field}
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		values = values[:0]
+		for i := range field.Len() {
+			values = append(values, field.Index(i))
+		}
+	}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if s := fmt.Sprint(value.Interface()); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// validateSpec calls the Validate hooks of a spec struct and its nested structs, innermost first.
+func validateSpec(path string, spec reflect.Value) []error {
+	for spec.Kind() == reflect.Pointer {
+		if spec.IsNil() {
+			return nil
+		}
+		spec = spec.Elem()
+	}
+	var errs []error
+	if spec.Kind() == reflect.Struct && spec.CanAddr() && !isLeafStruct(spec) {
+		for i := range spec.NumField() {
+			field := spec.Field(i)
+			ftype := spec.Type().Field(i)
+			if !field.CanSet() || isTrue(ftype.Tag.Get("ignored")) {
+				continue
+			}
+			errs = append(errs, validateSpec(path+"."+ftype.Name, field)...)
+		}
+	}
+	if !spec.CanAddr() {
+		return errs
+	}
+	if v, ok := spec.Addr().Interface().(Validator); ok {
+		if err := v.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("config validation error: %s: %w", path, err))
+		}
+	}
+	return errs
+}
+
+// isLeafStruct returns true if the struct is loaded from a single value, rather than field by field.
+func isLeafStruct(field reflect.Value) bool {
+	return setterFrom(field) != nil || textUnmarshaler(field) != nil || binaryUnmarshaler(field) != nil
+}
+
+// A validationError occurs when a loaded value violates the validation tags of a struct field.
+type validationError struct {
+	KeyName   string
+	FieldName string
+	Value     string
+	Reason    string
+}
+
+func (e *validationError) Error() string {
+	return fmt.Sprintf(
+		"config validation error: %[1]s (%[2]s): value '%[3]s' %[4]s",
+		e.KeyName,
+		e.FieldName,
+		e.Value,
+		e.Reason,
+	)
+}
@@ -0,0 +1,214 @@
+package cloudconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConfig_validationTags(t *testing.T) {
+	t.Parallel()
+	type spec struct {
+		Port     int           `default:"8080" min:"1" max:"65535"`
+		Ratio    float64       `default:"0.5" min:"0" max:"1"`
+		Timeout  time.Duration `default:"10s" min:"1s" max:"1m"`
+		Name     string        `default:"foo" nonempty:"true" max:"5"`
+		Tags     []string      `nonempty:"true" default:"a"`
+		Level    string        `default:"info" oneof:"debug,info,warn,error"`
+		Levels   []string      `default:"info" oneof:"debug,info"`
+		ID       string        `default:"abc" pattern:"^[a-z]+$"`
+		Endpoint string        `default:"https://example.com" url:"true"`
+		Password string        `default:"secret" secret:"true" pattern:"^[a-z]+$"`
+		Optional string        `pattern:"^[a-z]+$" url:"true" oneof:"a,b"`
+	}
+	for _, tt := range []struct {
+		name          string
+		env           map[string]string
+		expectedError string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "upper bounds",
+			env:  map[string]string{"PORT": "65535", "RATIO": "1", "TIMEOUT": "1m"},
+		},
+		{
+			name:          "int below min",
+			env:           map[string]string{"PORT": "0"},
+			expectedError: "config validation error: PORT (Port): value '0' must be at least 1",
+		},
+		{
+			name:          "int above max",
+			env:           map[string]string{"PORT": "65536"},
+			expectedError: "config validation error: PORT (Port): value '65536' must be at most 65535",
+		},
+		{
+			name:          "float above max",
+			env:           map[string]string{"RATIO": "1.5"},
+			expectedError: "config validation error: RATIO (Ratio): value '1.5' must be at most 1",
+		},
+		{
+			name:          "duration below min",
+			env:           map[string]string{"TIMEOUT": "500ms"},
+			expectedError: "config validation error: TIMEOUT (Timeout): value '500ms' must be at least 1s",
+		},
+		{
+			name:          "string length above max",
+			env:           map[string]string{"NAME": "foobar"},
+			expectedError: "config validation error: NAME (Name): value 'foobar' must be at most 5",
+		},
+		{
+			name:          "empty string",
+			env:           map[string]string{"NAME": ""},
+			expectedError: "config validation error: NAME (Name): value '' must not be empty",
+		},
+		{
+			name:          "empty slice",
+			env:           map[string]string{"TAGS": ""},
+			expectedError: "config validation error: TAGS (Tags): value '[]' must not be empty",
+		},
+		{
+			name:          "not one of",
+			env:           map[string]string{"LEVEL": "trace"},
+			expectedError: "config validation error: LEVEL (Level): value 'trace' must be one of [debug,info,warn,error]",
+		},
+		{
+			name:          "slice element not one of",
+			env:           map[string]string{"LEVELS": "debug,warn"},
+			expectedError: "config validation error: LEVELS (Levels): value '[debug warn]' must be one of [debug,info]",
+		},
+		{
+			name:          "pattern mismatch",
+			env:           map[string]string{"ID": "ABC"},
+			expectedError: "config validation error: ID (ID): value 'ABC' must match pattern ^[a-z]+$",
+		},
+		{
+			name:          "relative URL",
+			env:           map[string]string{"ENDPOINT": "/path"},
+			expectedError: "config validation error: ENDPOINT (Endpoint): value '/path' must be an absolute URL",
+		},
+		{
+			name:          "secret value is masked",
+			env:           map[string]string{"PASSWORD": "Hunter2"},
+			expectedError: "config validation error: PASSWORD (Password): value '<secret>' must match pattern ^[a-z]+$",
+		},
+		{
+			name: "empty values are only validated by nonempty",
+			env:  map[string]string{"OPTIONAL": ""},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			env := tt.env
+			if env == nil {
+				env = map[string]string{}
+			}
+			var s spec
+			config, err := New("test", &s, WithEnv(env))
+			assert.NilError(t, err)
+			err = config.Load()
+			if tt.expectedError != "" {
+				assert.Error(t, err, tt.expectedError)
+			} else {
+				assert.NilError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfig_validationTags_allErrors(t *testing.T) {
+	t.Parallel()
+	type spec struct {
+		Port  int    `default:"8080" min:"1"`
+		Level string `default:"info" oneof:"debug,info"`
+		Bool  bool   `min:"1"`
+	}
+	var s spec
+	config, err := New("test", &s, WithEnv(map[string]string{"PORT": "0", "LEVEL": "trace"}))
+	assert.NilError(t, err)
+	err = config.Load()
+	assert.Error(t, err, strings.Join([]string{
+		"config validation error: PORT (Port): value '0' must be at least 1",
+		"config validation error: LEVEL (Level): value 'trace' must be one of [debug,info]",
+		"config validation error: BOOL (Bool): value 'false' invalid min tag: unsupported type bool",
+	}, "\n"))
+}
+
+type validatorSpec struct {
+	Name  string `default:"outer"`
+	Inner validatorInner
+	calls *[]string
+}
+
+func (s *validatorSpec) Validate() error {
+	*s.calls = append(*s.calls, "outer")
+	if s.Name == "invalid" {
+		return errors.New("invalid name")
+	}
+	return nil
+}
+
+type validatorInner struct {
+	Value int `default:"1" min:"0"`
+	calls *[]string
+}
+
+func (s *validatorInner) Validate() error {
+	*s.calls = append(*s.calls, "inner")
+	if s.Value == 42 {
+		return errors.New("invalid value")
+	}
+	return nil
+}
+
+func TestConfig_Validator(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name          string
+		env           map[string]string
+		expectedCalls []string
+		expectedError string
+	}{
+		{
+			name:          "innermost first",
+			env:           map[string]string{},
+			expectedCalls: []string{"inner", "outer"},
+		},
+		{
+			name:          "all hooks are called",
+			env:           map[string]string{"NAME": "invalid", "INNER_VALUE": "42"},
+			expectedCalls: []string{"inner", "outer"},
+			expectedError: "config validation error: test.Inner: invalid value\n" +
+				"config validation error: test: invalid name",
+		},
+		{
+			name:          "not called when fields are invalid",
+			env:           map[string]string{"INNER_VALUE": "-1"},
+			expectedError: "config validation error: INNER_VALUE (Value): value '-1' must be at least 0",
+		},
+		{
+			name:          "not called when fields fail to parse",
+			env:           map[string]string{"INNER_VALUE": "foo"},
+			expectedError: "config parse error: assigning INNER_VALUE to Value: converting 'foo' to type int",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var calls []string
+			s := validatorSpec{calls: &calls, Inner: validatorInner{calls: &calls}}
+			config, err := New("test", &s, WithEnv(tt.env))
+			assert.NilError(t, err)
+			err = config.Load()
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.DeepEqual(t, tt.expectedCalls, calls)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package cloudconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// fileScheme is the URL scheme of config values that are read from a file.
+const fileScheme = "file://"
+
+// defaultReloadInterval is the default interval between reloads of a watched config.
+const defaultReloadInterval = 10 * time.Second
+
+// fileRef returns the filename of a file-backed config value, if any.
+func fileRef(value string) (string, bool) {
+	return strings.CutPrefix(value, fileScheme)
+}
+
+// readsFiles returns true if values of a field can be read from files, as file:// values or by _FILE-suffixed
+// environment variables. Fields opt in with the file or reload tag, other fields load such values as is.
+func readsFiles(tags reflect.StructTag) bool {
+	return isTrue(tags.Get("file")) || isTrue(tags.Get("reload"))
+}
+
+// Watcher holds the current snapshot of a watched config.
+type Watcher[T any] struct {
+	current atomic.Pointer[T]
+}
+
+// Current returns the current snapshot of the config.
+// Safe to call from any goroutine. The returned snapshot must not be modified.
+func (w *Watcher[T]) Current() *T {
+	return w.current.Load()
+}
+
+// Watch loads the config spec and then reloads fields tagged with `reload:"true"` at an interval,
+// until the context is done.
+//
+// Reloading is intended for file-backed values, such as file:///secrets/x or values referenced by a _FILE-suffixed
+// environment variable, that change when mounted secrets and config files are rotated. Fields tagged with reload can
+// always be loaded from files. Reloaded values are validated before they are applied. Each change is applied
+// atomically to a new snapshot, available from Watcher.Current, and onChange, when not nil, is called with the new
+// snapshot.
+//
+// Since snapshots are shallow copies of the spec, reloadable fields must not be nested behind pointers.
+func Watch[T any](
+	ctx context.Context,
+	spec *T,
+	onChange func(context.Context, *T),
+	options ...Option,
+) (*Watcher[T], error) {
+	config, err := New("config", spec, options...)
+	if err != nil {
+		return nil, fmt.Errorf("watch config: %w", err)
+	}
+	if err := config.LoadContext(ctx); err != nil {
+		return nil, fmt.Errorf("watch config: %w", err)
+	}
+	config.LogDeprecatedKeys(ctx)
+	var watcher Watcher[T]
+	watcher.current.Store(spec)
+	interval := config.reloadInterval
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			next, changed, err := reload(ctx, watcher.Current(), options)
+			if err != nil {
+				slog.WarnContext(ctx, "config reload rejected", slog.Any("error", err))
+				continue
+			}
+			if len(changed) == 0 {
+				continue
+			}
+			watcher.current.Store(next)
+			slog.InfoContext(ctx, "config reloaded", slog.Any("changed", changed))
+			if onChange != nil {
+				onChange(ctx, next)
+			}
+		}
+	}()
+	return &watcher, nil
+}
+
+// reload reloads the reloadable fields of a copy of the current snapshot, and returns the keys of changed fields.
+func reload[T any](ctx context.Context, current *T, options []Option) (*T, []string, error) {
+	next := new(T)
+	*next = *current
+	config, err := New("config", next, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var reloadable []fieldSpec
+	for _, cs := range config.configSpecs {
+		for _, fs := range cs.fieldSpecs {
+			if isTrue(fs.Tags.Get("reload")) {
+				reloadable = append(reloadable, fs)
+			}
+		}
+	}
+	if len(reloadable) == 0 {
+		return next, nil, nil
+	}
+	if err := config.loadSources(); err != nil {
+		return nil, nil, err
+	}
+	previous := make([]any, 0, len(reloadable))
+	for _, fs := range reloadable {
+		previous = append(previous, fs.Value.Interface())
+	}
+	if err := config.process(ctx, reloadable); err != nil {
+		return nil, nil, err
+	}
+	if errs := validateSpec("config", reflect.ValueOf(next)); len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	var changed []string
+	for i, fs := range reloadable {
+		if !reflect.DeepEqual(previous[i], fs.Value.Interface()) {
+			changed = append(changed, fs.Key)
+		}
+	}
+	return next, changed, nil
+}
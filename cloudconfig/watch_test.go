@@ -0,0 +1,237 @@
+package cloudconfig
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+// replaceFile atomically replaces the content of a file, as when mounted secrets are rotated.
+func replaceFile(t *testing.T, filename, content string) {
+	t.Helper()
+	tmp := filename + ".tmp"
+	assert.NilError(t, os.WriteFile(tmp, []byte(content), 0o600))
+	assert.NilError(t, os.Rename(tmp, filename))
+}
+
+type watchSpec struct {
+	Name   string `default:"name"`
+	Token  string `reload:"true"`
+	Limit  int    `reload:"true" min:"1"`
+	Static string `file:"true"`
+}
+
+func TestConfig_fileBackedValues(t *testing.T) {
+	t.Parallel()
+	type spec struct {
+		Secret   string `file:"true"`
+		Cert     string
+		CertFile string `env:"CERT_FILE"`
+		Endpoint url.URL
+		Input    string
+	}
+	secretFile := writeFile(t, "secret", "from-file\n")
+	for _, tt := range []struct {
+		name          string
+		env           map[string]string
+		expected      spec
+		expectedError string
+	}{
+		{
+			name:     "file value",
+			env:      map[string]string{"SECRET": "file://" + secretFile},
+			expected: spec{Secret: "from-file"},
+		},
+		{
+			name:     "_FILE variable",
+			env:      map[string]string{"SECRET_FILE": secretFile},
+			expected: spec{Secret: "from-file"},
+		},
+		{
+			name:          "missing file",
+			env:           map[string]string{"SECRET": "file:///missing/secret"},
+			expectedError: "read file for key SECRET",
+		},
+		{
+			// Fields without the file tag load file:// values as is.
+			name: "file values of ordinary fields",
+			env: map[string]string{
+				"ENDPOINT": "file://" + secretFile,
+				"INPUT":    "file:///data/input.csv",
+			},
+			expected: spec{
+				Endpoint: url.URL{Scheme: "file", Path: secretFile},
+				Input:    "file:///data/input.csv",
+			},
+		},
+		{
+			// Fields without the file tag are not loaded from _FILE variables, which can be keys of other fields.
+			name:     "_FILE variables of ordinary fields",
+			env:      map[string]string{"CERT_FILE": secretFile},
+			expected: spec{CertFile: secretFile},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s spec
+			config, err := New("test", &s, WithEnv(tt.env))
+			assert.NilError(t, err)
+			err = config.Load()
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expected, s)
+		})
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+		var s spec
+		config, err := New(
+			"test",
+			&s,
+			WithEnv(map[string]string{}),
+			WithDotEnvFile(writeFile(t, ".env", "SECRET_FILE="+secretFile+"\nINPUT_FILE=/data/input.csv\n")),
+			WithStrict(),
+		)
+		assert.NilError(t, err)
+		// _FILE variables are only consumed by fields with the file tag.
+		assert.Error(t, config.Load(), "strict config: unknown env INPUT_FILE in dotenv")
+	})
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	waitFor := func(t *testing.T, condition func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatal("condition not met before deadline")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	t.Run("reloads file-backed values", func(t *testing.T) {
+		t.Parallel()
+		tokenFile := writeFile(t, "token", "first\n")
+		staticFile := writeFile(t, "static", "first\n")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		changes := make(chan *watchSpec, 10)
+		watcher, err := Watch(
+			ctx,
+			&watchSpec{},
+			func(_ context.Context, spec *watchSpec) { changes <- spec },
+			WithEnv(map[string]string{"TOKEN_FILE": tokenFile, "LIMIT": "10", "STATIC_FILE": staticFile}),
+			WithReloadInterval(time.Millisecond),
+		)
+		assert.NilError(t, err)
+		initial := watcher.Current()
+		assert.Equal(t, "first", initial.Token)
+		assert.Equal(t, "first", initial.Static)
+		replaceFile(t, tokenFile, "second\n")
+		replaceFile(t, staticFile, "second\n")
+		next := <-changes
+		assert.Equal(t, "second", next.Token)
+		assert.Equal(t, next, watcher.Current())
+		// Fields without the reload tag keep their loaded values.
+		assert.Equal(t, "first", next.Static)
+		assert.Equal(t, "name", next.Name)
+		assert.Equal(t, 10, next.Limit)
+		// Previous snapshots are never modified.
+		assert.Equal(t, "first", initial.Token)
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Parallel()
+		limitFile := writeFile(t, "limit", "10")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		changes := make(chan *watchSpec, 10)
+		watcher, err := Watch(
+			ctx,
+			&watchSpec{},
+			func(_ context.Context, spec *watchSpec) { changes <- spec },
+			WithEnv(map[string]string{"LIMIT_FILE": limitFile}),
+			WithReloadInterval(time.Millisecond),
+		)
+		assert.NilError(t, err)
+		replaceFile(t, limitFile, "0")
+		// Wait for several reload intervals for the invalid value to be rejected.
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, 10, watcher.Current().Limit)
+		assert.Equal(t, 0, len(changes))
+		replaceFile(t, limitFile, "20")
+		assert.Equal(t, 20, (<-changes).Limit)
+	})
+
+	t.Run("fails on invalid initial values", func(t *testing.T) {
+		t.Parallel()
+		_, err := Watch(
+			context.Background(),
+			&watchSpec{},
+			nil,
+			WithEnv(map[string]string{"LIMIT": "0"}),
+		)
+		assert.ErrorContains(t, err, "watch config: config validation error: LIMIT (Limit)")
+	})
+
+	t.Run("stops when context is done", func(t *testing.T) {
+		t.Parallel()
+		tokenFile := writeFile(t, "token", "first")
+		ctx, cancel := context.WithCancel(context.Background())
+		watcher, err := Watch(
+			ctx,
+			&watchSpec{},
+			nil,
+			WithEnv(map[string]string{"TOKEN_FILE": tokenFile, "LIMIT": "1"}),
+			WithReloadInterval(time.Millisecond),
+		)
+		assert.NilError(t, err)
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		replaceFile(t, tokenFile, "second")
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, "first", watcher.Current().Token)
+	})
+
+	t.Run("concurrent reads", func(t *testing.T) {
+		t.Parallel()
+		tokenFile := writeFile(t, "token", "0")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		watcher, err := Watch(
+			ctx,
+			&watchSpec{},
+			nil,
+			WithEnv(map[string]string{"TOKEN_FILE": tokenFile, "LIMIT": "1"}),
+			WithReloadInterval(time.Millisecond),
+		)
+		assert.NilError(t, err)
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Go(func() {
+				for ctx.Err() == nil {
+					snapshot := watcher.Current()
+					_ = snapshot.Token
+					_ = snapshot.Limit
+				}
+			})
+		}
+		for _, token := range []string{"1", "2", "3"} {
+			replaceFile(t, tokenFile, token)
+			waitFor(t, func() bool { return watcher.Current().Token == token })
+		}
+		cancel()
+		wg.Wait()
+	})
+}
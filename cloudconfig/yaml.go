@@ -4,10 +4,16 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// maxContainers is the maximum number of containers of a Cloud Run service, job or worker pool.
+const maxContainers = 10
+
 type env struct {
 	Name      string
 	Value     string
@@ -19,7 +25,49 @@ type env struct {
 	} `yaml:"valueFrom"`
 }
 
-func getEnvFromYAMLServiceSpecificationFile(name string) (envValues []env, err error) {
+type container struct {
+	Name         string
+	Env          []env
+	VolumeMounts []struct {
+		Name      string
+		MountPath string `yaml:"mountPath"`
+	} `yaml:"volumeMounts"`
+}
+
+type volume struct {
+	Name   string
+	Secret struct {
+		SecretName string `yaml:"secretName"`
+		Items      []struct {
+			Key  string
+			Path string
+		}
+	}
+}
+
+type podSpec struct {
+	Containers []container
+	Volumes    []volume
+}
+
+// yamlServiceSpecification is the config of a container in a YAML service specification file.
+type yamlServiceSpecification struct {
+	// serviceName is the name of the service, job or worker pool.
+	serviceName string
+	// envs is the env of the container, with $(VAR) references expanded.
+	envs []env
+	// secretFiles maps the paths of files in secret volumes mounted by the container to Secret Manager references.
+	secretFiles map[string]string
+}
+
+// getEnvFromYAMLServiceSpecificationFile returns the config of a container in a YAML service specification file.
+//
+// The container is selected by name when containerName is not empty. Otherwise, the container with the same name
+// as the running binary is selected, falling back to the first container.
+func getEnvFromYAMLServiceSpecificationFile(
+	name string,
+	containerName string,
+) (result yamlServiceSpecification, err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("set env from YAML service/job specification file %s: %w", name, err)
@@ -30,82 +78,165 @@ func getEnvFromYAMLServiceSpecificationFile(name string) (envValues []env, err e
 	}
 	data, err := os.ReadFile(name)
 	if err != nil {
-		return nil, err
+		return yamlServiceSpecification{}, err
 	}
 	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&kind); err != nil {
-		return nil, err
+		return yamlServiceSpecification{}, err
 	}
-	var envs []env
+	var metadata struct {
+		Name      string
+		Namespace string
+	}
+	var pod podSpec
 	switch kind.Kind {
 	case "Service", "WorkerPool": // Cloud Run Services and Worker Pools
 		var config struct {
 			Metadata struct {
-				Name string
+				Name      string
+				Namespace string
 			}
 			Spec struct {
 				Template struct {
-					Spec struct {
-						Containers []struct {
-							Env []env
-						}
-					}
+					Spec podSpec
 				}
 			}
 		}
 		if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&config); err != nil {
-			return nil, err
-		}
-		containers := config.Spec.Template.Spec.Containers
-		if len(containers) == 0 || len(containers) > 10 {
-			return nil, fmt.Errorf("unexpected number of containers: %d", len(containers))
-		}
-		if config.Metadata.Name != "" {
-			if err := os.Setenv("K_SERVICE", config.Metadata.Name); err != nil {
-				return nil, err
-			}
+			return yamlServiceSpecification{}, err
 		}
-		envs = containers[0].Env
+		metadata, pod = config.Metadata, config.Spec.Template.Spec
 	case "Job": // Cloud Run Jobs
 		var config struct {
 			Metadata struct {
-				Name string
+				Name      string
+				Namespace string
 			}
 			Spec struct {
 				Template struct {
 					Spec struct {
 						Template struct {
-							Spec struct {
-								Containers []struct {
-									Env []env
-								}
-							}
+							Spec podSpec
 						}
 					}
 				}
 			}
 		}
 		if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&config); err != nil {
-			return nil, err
+			return yamlServiceSpecification{}, err
 		}
-		containers := config.Spec.Template.Spec.Template.Spec.Containers
-		if len(containers) == 0 || len(containers) > 10 {
-			return nil, fmt.Errorf("unexpected number of containers: %d", len(containers))
+		metadata, pod = config.Metadata, config.Spec.Template.Spec.Template.Spec
+	default:
+		return yamlServiceSpecification{}, fmt.Errorf("unknown config kind: %s", kind.Kind)
+	}
+	names := make([]string, 0, len(pod.Containers))
+	for _, c := range pod.Containers {
+		names = append(names, c.Name)
+	}
+	i, err := selectContainer(names, containerName)
+	if err != nil {
+		return yamlServiceSpecification{}, err
+	}
+	return yamlServiceSpecification{
+		serviceName: metadata.Name,
+		envs:        expandEnvReferences(pod.Containers[i].Env),
+		secretFiles: secretFiles(pod.Containers[i], pod.Volumes, metadata.Namespace),
+	}, nil
+}
+
+// selectContainer returns the index of the container to load config from, given the names of all containers.
+func selectContainer(names []string, containerName string) (int, error) {
+	if len(names) == 0 || len(names) > maxContainers {
+		return 0, fmt.Errorf("unexpected number of containers: %d", len(names))
+	}
+	if containerName != "" {
+		for i, name := range names {
+			if name == containerName {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("container %s not found, expected one of: %s", containerName, strings.Join(names, ", "))
+	}
+	binaryName := filepath.Base(os.Args[0])
+	for i, name := range names {
+		if name == binaryName {
+			return i, nil
 		}
-		if config.Metadata.Name != "" {
-			if err := os.Setenv("K_SERVICE", config.Metadata.Name); err != nil {
-				return nil, err
+	}
+	return 0, nil
+}
+
+// expandEnvReferences expands $(VAR) references to variables defined earlier in the env, as done by Cloud Run.
+// References to undefined variables are left as is, and $$ is an escaped $.
+func expandEnvReferences(envs []env) []env {
+	defined := make(map[string]string, len(envs))
+	result := make([]env, 0, len(envs))
+	for _, e := range envs {
+		e.Value = expandEnvReference(e.Value, defined)
+		defined[e.Name] = e.Value
+		result = append(result, e)
+	}
+	return result
+}
+
+func expandEnvReference(value string, defined map[string]string) string {
+	if !strings.Contains(value, "$") {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		switch value[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '(':
+			end := strings.IndexByte(value[i+2:], ')')
+			if end < 0 {
+				b.WriteString(value[i:])
+				return b.String()
+			}
+			reference := value[i : i+3+end]
+			if resolved, ok := defined[reference[2:len(reference)-1]]; ok {
+				b.WriteString(resolved)
+			} else {
+				b.WriteString(reference)
 			}
+			i += len(reference) - 1
+		default:
+			b.WriteByte('$')
 		}
-		envs = containers[0].Env
-	default:
-		return nil, fmt.Errorf("unknown config kind: %s", kind.Kind)
 	}
-	envValues = make([]env, 0, len(envs))
-	for _, env := range envs {
-		// Prefer variables from local environment.
-		if _, ok := os.LookupEnv(env.Name); !ok {
-			envValues = append(envValues, env)
+	return b.String()
+}
+
+// secretFiles returns the paths of files in secret volumes mounted by the container, mapped to references to their
+// Secret Manager secret versions. The project of the secrets is the namespace of the specification, and secret volumes
+// are not resolved when the namespace is not known.
+func secretFiles(c container, volumes []volume, namespace string) map[string]string {
+	if namespace == "" {
+		return nil
+	}
+	result := map[string]string{}
+	for _, mount := range c.VolumeMounts {
+		for _, v := range volumes {
+			if v.Name != mount.Name || v.Secret.SecretName == "" {
+				continue
+			}
+			secret := v.Secret.SecretName
+			if !strings.HasPrefix(secret, "projects/") {
+				secret = "projects/" + namespace + "/secrets/" + secret
+			}
+			if len(v.Secret.Items) == 0 {
+				// Without items, the latest version is mounted at a file named after the secret.
+				result[path.Join(mount.MountPath, v.Secret.SecretName)] = secret + "/versions/latest"
+			}
+			for _, item := range v.Secret.Items {
+				result[path.Join(mount.MountPath, item.Path)] = secret + "/versions/" + item.Key
+			}
 		}
 	}
-	return envValues, nil
+	return result
 }
@@ -0,0 +1,233 @@
+package cloudconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+)
+
+func TestExpandEnvReference(t *testing.T) {
+	t.Parallel()
+	defined := map[string]string{"HOST": "localhost", "PORT": "8080", "EMPTY": ""}
+	for _, tt := range []struct {
+		value    string
+		expected string
+	}{
+		{value: "", expected: ""},
+		{value: "plain", expected: "plain"},
+		{value: "$(HOST)", expected: "localhost"},
+		{value: "http://$(HOST):$(PORT)/path", expected: "http://localhost:8080/path"},
+		{value: "$(EMPTY)", expected: ""},
+		{value: "$(UNDEFINED)", expected: "$(UNDEFINED)"},
+		{value: "$$(HOST)", expected: "$(HOST)"},
+		{value: "$$$(HOST)", expected: "$localhost"},
+		{value: "cost: $5", expected: "cost: $5"},
+		{value: "trailing $", expected: "trailing $"},
+		{value: "$(HOST", expected: "$(HOST"},
+		{value: "$(HOST)$(", expected: "localhost$("},
+	} {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, expandEnvReference(tt.value, defined))
+		})
+	}
+}
+
+func TestExpandEnvReferences(t *testing.T) {
+	t.Parallel()
+	envs := expandEnvReferences([]env{
+		{Name: "A", Value: "$(B)"},
+		{Name: "B", Value: "b"},
+		{Name: "C", Value: "$(A)-$(B)"},
+		{Name: "D", Value: "$(C)"},
+	})
+	values := make(map[string]string, len(envs))
+	for _, e := range envs {
+		values[e.Name] = e.Value
+	}
+	// References are only expanded to variables defined earlier in the env.
+	assert.DeepEqual(t, map[string]string{"A": "$(B)", "B": "b", "C": "$(B)-b", "D": "$(B)-b"}, values)
+}
+
+func TestSelectContainer(t *testing.T) {
+	t.Parallel()
+	binaryName := filepath.Base(os.Args[0])
+	for _, tt := range []struct {
+		name          string
+		names         []string
+		containerName string
+		expected      int
+		expectedError string
+	}{
+		{name: "single", names: []string{"app"}, expected: 0},
+		{name: "first by default", names: []string{"app", "sidecar"}, expected: 0},
+		{name: "binary name", names: []string{"sidecar", binaryName}, expected: 1},
+		{name: "by name", names: []string{"app", "sidecar", binaryName}, containerName: "sidecar", expected: 1},
+		{
+			name:          "not found",
+			names:         []string{"app", "sidecar"},
+			containerName: "other",
+			expectedError: "container other not found, expected one of: app, sidecar",
+		},
+		{name: "none", expectedError: "unexpected number of containers: 0"},
+		{
+			name:          "too many",
+			names:         make([]string, maxContainers+1),
+			expectedError: "unexpected number of containers: 11",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := selectContainer(tt.names, tt.containerName)
+			if tt.expectedError != "" {
+				assert.Error(t, err, tt.expectedError)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestSecretFiles(t *testing.T) {
+	t.Parallel()
+	const content = `containers:
+  - name: app
+    volumeMounts:
+      - name: certs
+        mountPath: /certs
+      - name: api-key
+        mountPath: /secrets/api-key
+      - name: token
+        mountPath: /secrets/token
+      - name: config
+        mountPath: /config
+volumes:
+  - name: certs
+    secret:
+      secretName: tls
+      items:
+        - key: "1"
+          path: cert.pem
+        - key: latest
+          path: key.pem
+  - name: api-key
+    secret:
+      secretName: api-key
+  - name: token
+    secret:
+      secretName: projects/other-project/secrets/token
+      items:
+        - key: "2"
+          path: token
+  - name: config
+    emptyDir: {}
+  - name: unmounted
+    secret:
+      secretName: unmounted
+`
+	var pod podSpec
+	assert.NilError(t, yaml.Unmarshal([]byte(content), &pod))
+
+	t.Run("resolved in namespace", func(t *testing.T) {
+		t.Parallel()
+		expected := map[string]string{
+			"/certs/cert.pem": "projects/my-project/secrets/tls/versions/1",
+			"/certs/key.pem":  "projects/my-project/secrets/tls/versions/latest",
+			// Without items, the latest version is mounted at a file named after the secret.
+			"/secrets/api-key/api-key": "projects/my-project/secrets/api-key/versions/latest",
+			// Secrets with a full resource name are not resolved in the namespace.
+			"/secrets/token/token": "projects/other-project/secrets/token/versions/2",
+		}
+		assert.DeepEqual(t, expected, secretFiles(pod.Containers[0], pod.Volumes, "my-project"))
+	})
+
+	t.Run("not resolved without namespace", func(t *testing.T) {
+		t.Parallel()
+		assert.Assert(t, secretFiles(pod.Containers[0], pod.Volumes, "") == nil)
+	})
+}
+
+func TestConfig_yamlServiceSpecification(t *testing.T) {
+	t.Parallel()
+	const content = `apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: my-service
+  namespace: my-project
+spec:
+  template:
+    spec:
+      containers:
+        - name: sidecar
+          env:
+            - name: HOST
+              value: sidecar
+        - name: app
+          env:
+            - name: HOST
+              value: localhost
+            - name: URL
+              value: http://$(HOST):8080
+            - name: CERT
+              value: file:///certs/cert.pem
+          volumeMounts:
+            - name: certs
+              mountPath: /certs
+      volumes:
+        - name: certs
+          secret:
+            secretName: tls
+            items:
+              - key: "1"
+                path: cert.pem
+`
+	type spec struct {
+		Service string `env:"K_SERVICE"`
+		Host    string
+		URL     string
+		Cert    string `file:"true"`
+	}
+	filename := writeFile(t, "service.yaml", content)
+	for _, tt := range []struct {
+		name          string
+		containerName string
+		expected      spec
+	}{
+		{
+			name:          "app",
+			containerName: "app",
+			expected: spec{
+				Service: "my-service",
+				Host:    "localhost",
+				URL:     "http://localhost:8080",
+				Cert:    "certificate",
+			},
+		},
+		{
+			name:     "first container",
+			expected: spec{Service: "my-service", Host: "sidecar"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s spec
+			config, err := New(
+				"test",
+				&s,
+				WithEnv(map[string]string{}),
+				WithYAMLServiceSpecificationFile(filename),
+				WithYAMLContainerName(tt.containerName),
+				WithSecretResolver(MapSecretResolver{
+					"projects/my-project/secrets/tls/versions/1": "certificate",
+				}),
+			)
+			assert.NilError(t, err)
+			assert.NilError(t, config.Load())
+			assert.DeepEqual(t, tt.expected, s)
+		})
+	}
+}
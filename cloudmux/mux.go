@@ -8,9 +8,11 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/soheilhy/cmux"
+	"go.einride.tech/cloudrunner/cloudserver"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 )
@@ -23,7 +25,7 @@ type muxConfig struct {
 }
 
 // WithShutdownTimeout sets the maximum duration to wait for in-flight requests
-// to complete during graceful shutdown. Defaults to 5s.
+// to complete during graceful shutdown. Defaults to 5s. Zero means no timeout.
 func WithShutdownTimeout(d time.Duration) Option {
 	return func(c *muxConfig) {
 		c.shutdownTimeout = d
@@ -32,7 +34,8 @@ func WithShutdownTimeout(d time.Duration) Option {
 
 // ServeGRPCHTTP serves both a gRPC and an HTTP server on listener l.
 // When the context is canceled, the servers will be gracefully shutdown and
-// then the function will return.
+// then the function will return. Servers that have not completed their in-flight
+// requests within the shutdown timeout are forcefully stopped.
 func ServeGRPCHTTP(
 	ctx context.Context,
 	l net.Listener,
@@ -56,15 +59,23 @@ func ServeGRPCHTTP(
 		<-ctx.Done()
 		slog.DebugContext(ctx, "stopping cmux server")
 		m.Close()
-		slog.DebugContext(ctx, "stopping HTTP server")
 		// use a new context because the parent ctx is already canceled.
-		ctx, cancel := context.WithTimeout(context.Background(), cfg.shutdownTimeout)
+		ctx, cancel := shutdownContext(context.WithoutCancel(ctx), cfg.shutdownTimeout)
 		defer cancel()
-		if err := httpServer.Shutdown(ctx); err != nil && !isClosedErr(err) {
-			slog.WarnContext(ctx, "stopping http server", slog.Any("error", err))
-		}
-		slog.DebugContext(ctx, "stopping gRPC server")
-		grpcServer.GracefulStop()
+		// drain both servers concurrently, so that they share the shutdown timeout.
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			slog.DebugContext(ctx, "stopping HTTP server")
+			if err := httpServer.Shutdown(ctx); err != nil && !isClosedErr(err) {
+				slog.WarnContext(ctx, "stopping http server", slog.Any("error", err))
+				_ = httpServer.Close()
+			}
+		})
+		wg.Go(func() {
+			slog.DebugContext(ctx, "stopping gRPC server")
+			_ = cloudserver.GracefulStopGRPC(ctx, grpcServer)
+		})
+		wg.Wait()
 		slog.DebugContext(ctx, "stopped both http and grpc server")
 		return nil
 	})
@@ -94,6 +105,14 @@ func ServeGRPCHTTP(
 	return g.Wait()
 }
 
+// shutdownContext returns a context for graceful shutdown, bounded by the shutdown timeout when positive.
+func shutdownContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 func isClosedErr(err error) bool {
 	return isClosedConnErr(err) ||
 		errors.Is(err, http.ErrServerClosed) ||
@@ -22,11 +22,11 @@ import (
 
 // MetricExporterConfig configures the metrics exporter.
 type MetricExporterConfig struct {
-	Enabled                bool          `onGCE:"false"`
-	Interval               time.Duration `default:"60s"`
-	RuntimeInstrumentation bool          `onGCE:"true"`
-	HostInstrumentation    bool          `onGCE:"true"`
-	OpenCensusProducer     bool          `default:"false"`
+	Enabled                bool          `onGCE:"false" desc:"Enable the Cloud Monitoring metric exporter"`
+	Interval               time.Duration `default:"60s" desc:"Interval between metric exports"`
+	RuntimeInstrumentation bool          `onGCE:"true" desc:"Export Go runtime metrics"`
+	HostInstrumentation    bool          `onGCE:"true" desc:"Export host metrics"`
+	OpenCensusProducer     bool          `default:"false" desc:"Export OpenCensus metrics"`
 	// DropMetrics is a list of metric names to drop. Supports wildcards (e.g., "http.client.*").
 	// This can be used to reduce cost and cardinality by excluding unwanted metrics.
 	DropMetrics []string
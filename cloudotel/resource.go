@@ -30,6 +30,9 @@ func NewResource(ctx context.Context) (*resource.Resource, error) {
 
 // NewResourceWithConfig creates and detects attributes for a new OpenTelemetry resource, with optional tolerance for
 // partial resources and schema URL conflicts configured via ResourceConfig.
+//
+// The service name is taken from the runtime config of the context, when set with
+// [cloudruntime.WithConfig], and otherwise from the environment.
 func NewResourceWithConfig(ctx context.Context, config ResourceConfig) (*resource.Resource, error) {
 	opts := []resource.Option{
 		resource.WithTelemetrySDK(),
@@ -43,7 +46,9 @@ func NewResourceWithConfig(ctx context.Context, config ResourceConfig) (*resourc
 	if e, ok := cloudruntime.TaskIndex(); ok {
 		opts = append(opts, resource.WithAttributes(semconv.FaaSInstanceKey.String(strconv.Itoa(e))))
 	}
-	if e, ok := cloudruntime.Service(); ok {
+	if runtimeConfig, ok := cloudruntime.GetConfig(ctx); ok && runtimeConfig.Service != "" {
+		opts = append(opts, resource.WithAttributes(semconv.ServiceName(runtimeConfig.Service)))
+	} else if e, ok := cloudruntime.Service(); ok {
 		opts = append(opts, resource.WithAttributes(semconv.ServiceName(e)))
 	}
 	result, err := resource.New(ctx, opts...)
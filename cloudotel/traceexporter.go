@@ -17,9 +17,9 @@ import (
 
 // TraceExporterConfig configures the trace exporter.
 type TraceExporterConfig struct {
-	Enabled           bool          `onGCE:"true"`
-	Timeout           time.Duration `default:"10s"`
-	SampleProbability float64       `default:"0.01"`
+	Enabled           bool          `onGCE:"true" desc:"Enable the Cloud Trace exporter"`
+	Timeout           time.Duration `default:"10s" min:"0" desc:"Timeout of trace exports"`
+	SampleProbability float64       `default:"0.01" min:"0" max:"1" desc:"Sample probability"`
 }
 
 // StartTraceExporter starts the OpenTelemetry Cloud Trace exporter.
@@ -18,11 +18,11 @@ var (
 // Config configures the use of Google Cloud Profiler.
 type Config struct {
 	// Enabled indicates if the profiler should be enabled.
-	Enabled bool `onGCE:"true"`
+	Enabled bool `onGCE:"true" desc:"Enable Cloud Profiler"`
 	// MutexProfiling indicates if mutex profiling should be enabled.
-	MutexProfiling bool
+	MutexProfiling bool `desc:"Enable mutex profiling"`
 	// AllocForceGC indicates if GC should be forced before allocation profiling snapshots are taken.
-	AllocForceGC bool `default:"true"`
+	AllocForceGC bool `default:"true" desc:"Force GC before allocation profiling snapshots"`
 }
 
 // Start the profiler according to the provided Config.
@@ -11,7 +11,7 @@ type Config struct {
 	// MessageSizeLimit is the maximum size, in bytes, of requests and responses to log.
 	// Messages larger than the limit will be truncated.
 	// Default value, 0, means that no messages will be truncated.
-	MessageSizeLimit int `onGCE:"1024"`
+	MessageSizeLimit int `onGCE:"1024" desc:"Max size of logged messages, 0 for none"`
 	// CodeToLevel enables overriding the default gRPC code to level conversion.
 	CodeToLevel map[codes.Code]slog.Level
 	// StatusToLevel enables overriding the default HTTP status code to level conversion.
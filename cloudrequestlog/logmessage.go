@@ -32,3 +32,15 @@ func httpServerLogMessage(res *httpResponseWriter, req *http.Request) string {
 		strings.TrimPrefix(req.RequestURI, "/"),
 	)
 }
+
+func httpClientLogMessage(res *http.Response, req *http.Request) string {
+	var statusCode int
+	if res != nil {
+		statusCode = res.StatusCode
+	}
+	var host, path string
+	if req.URL != nil {
+		host, path = req.URL.Host, strings.TrimPrefix(req.URL.Path, "/")
+	}
+	return fmt.Sprintf("HTTPClient %d %s/%s", statusCode, host, path)
+}
@@ -3,9 +3,12 @@ package cloudrequestlog
 import (
 	"context"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.einride.tech/cloudrunner/cloudstream"
@@ -163,6 +166,124 @@ func (l *Middleware) GRPCUnaryClientInterceptor(
 	return err
 }
 
+// GRPCStreamClientInterceptor provides request logging as a grpc.StreamClientInterceptor.
+// The request is logged when the final status of the stream is received, and includes the number and size of the
+// messages sent and received, but not the message payloads.
+func (l *Middleware) GRPCStreamClientInterceptor(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	fullMethod string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	stream := &loggingClientStream{
+		middleware: l,
+		ctx:        ctx,
+		desc:       desc,
+		fullMethod: fullMethod,
+		startTime:  time.Now(),
+		// assuming this middleware is first in the chain, the caller of the client method is 4 stack frames up
+		source: newSourceAttr(runtime.Caller(4)),
+	}
+	clientStream, err := streamer(ctx, desc, cc, fullMethod, opts...)
+	if err != nil {
+		stream.finish(err)
+		return nil, err
+	}
+	stream.ClientStream = clientStream
+	// Streams abandoned by the caller before receiving the final status are logged when the context is done.
+	stop := context.AfterFunc(ctx, func() {
+		stream.finish(status.FromContextError(ctx.Err()).Err())
+	})
+	stream.stopAfterFunc.Store(&stop)
+	return stream, nil
+}
+
+// loggingClientStream is a grpc.ClientStream that logs the final status of the stream.
+type loggingClientStream struct {
+	grpc.ClientStream
+	middleware       *Middleware
+	ctx              context.Context
+	desc             *grpc.StreamDesc
+	fullMethod       string
+	startTime        time.Time
+	source           slog.Attr
+	sentMessages     atomic.Int64
+	sentBytes        atomic.Int64
+	receivedMessages atomic.Int64
+	receivedBytes    atomic.Int64
+	stopAfterFunc    atomic.Pointer[func() bool]
+	once             sync.Once
+}
+
+// SendMsg implements grpc.ClientStream.
+func (s *loggingClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.sentMessages.Add(1)
+		if message, ok := m.(proto.Message); ok {
+			s.sentBytes.Add(int64(proto.Size(message)))
+		}
+	}
+	return err
+}
+
+// RecvMsg implements grpc.ClientStream.
+func (s *loggingClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case errors.Is(err, io.EOF):
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	default:
+		s.receivedMessages.Add(1)
+		if message, ok := m.(proto.Message); ok {
+			s.receivedBytes.Add(int64(proto.Size(message)))
+		}
+		// Streams without server streaming are done after the first response.
+		if !s.desc.ServerStreams {
+			s.finish(nil)
+		}
+	}
+	return err
+}
+
+// finish logs the final status of the stream, once.
+func (s *loggingClientStream) finish(err error) {
+	s.once.Do(func() {
+		if stop := s.stopAfterFunc.Load(); stop != nil {
+			(*stop)()
+		}
+		responseStatus := status.Convert(err)
+		level := s.middleware.codeToLevel(responseStatus.Code())
+		logger := slog.Default()
+		if !logger.Enabled(s.ctx, level) {
+			return
+		}
+		grpcRequest := &ltype.HttpRequest{
+			Protocol:     "gRPC",
+			Latency:      durationpb.New(time.Since(s.startTime)),
+			RequestSize:  s.sentBytes.Load(),
+			ResponseSize: s.receivedBytes.Load(),
+		}
+		attrs := []slog.Attr{
+			slog.String("code", responseStatus.Code().String()),
+			slog.Any("status", responseStatus),
+			slog.Any("httpRequest", grpcRequest),
+			slog.Int64("sentMessages", s.sentMessages.Load()),
+			slog.Int64("receivedMessages", s.receivedMessages.Load()),
+			s.source,
+		}
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+		}
+		attrs = appendFullMethodAttrs(s.fullMethod, attrs)
+		logger.LogAttrs(s.ctx, level, grpcClientLogMessage(responseStatus.Code(), s.fullMethod), attrs...)
+	})
+}
+
 func newSourceAttr(pc uintptr, file string, line int, _ bool) slog.Attr {
 	return slog.Any(
 		slog.SourceKey,
@@ -257,3 +378,53 @@ func appendFullMethodAttrs(fullMethod string, dst []slog.Attr) []slog.Attr {
 		slog.String("method", method),
 	)
 }
+
+// HTTPClient provides request logging for HTTP clients.
+func (l *Middleware) HTTPClient(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		startTime := time.Now()
+		ctx := r.Context()
+		response, err := next.RoundTrip(r)
+		var level slog.Level
+		if err != nil {
+			level = l.codeToLevel(status.FromContextError(err).Code())
+		} else {
+			level = l.statusToLevel(response.StatusCode)
+		}
+		logger := slog.Default()
+		if !logger.Enabled(ctx, level) {
+			return response, err
+		}
+		httpRequest := &ltype.HttpRequest{
+			RequestMethod: r.Method,
+			RequestSize:   r.ContentLength,
+			UserAgent:     r.UserAgent(),
+			Referer:       r.Referer(),
+			Latency:       durationpb.New(time.Since(startTime)),
+		}
+		if r.URL != nil {
+			httpRequest.RequestUrl = r.URL.String()
+		}
+		if response != nil {
+			httpRequest.Status = int32(response.StatusCode)
+			httpRequest.ResponseSize = response.ContentLength + int64(measureHeaderSize(response.Header))
+			httpRequest.Protocol = response.Proto
+		}
+		attrs := []slog.Attr{
+			slog.Any("httpRequest", httpRequest),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+		}
+		logger.LogAttrs(ctx, level, httpClientLogMessage(response, r), attrs...)
+		return response, err
+	})
+}
+
+// roundTripperFunc is a function that implements http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
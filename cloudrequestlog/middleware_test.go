@@ -0,0 +1,211 @@
+package cloudrequestlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"gotest.tools/v3/assert"
+)
+
+// The tests in this file are not parallel, since they replace the default logger.
+
+func TestMiddleware_GRPCStreamClientInterceptor(t *testing.T) {
+	const fullMethod = "/test.Service/Stream"
+	serverStreams := &grpc.StreamDesc{ServerStreams: true}
+
+	t.Run("received final status", func(t *testing.T) {
+		handler := captureLogs(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream := newStream(ctx, t, serverStreams, &fakeClientStream{responses: 2})
+		for {
+			if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+				assert.Equal(t, io.EOF, err)
+				break
+			}
+		}
+		// Streams are only logged once, also when the context is done after the final status.
+		cancel()
+		record := handler.wait(t)
+		assert.Equal(t, "gRPCClient OK Stream", record.Message)
+		assert.Equal(t, slog.LevelInfo, record.Level)
+		assert.Equal(t, int64(2), attr(record, "receivedMessages").Int64())
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, 0, handler.len())
+	})
+
+	t.Run("received error", func(t *testing.T) {
+		handler := captureLogs(t)
+		stream := newStream(context.Background(), t, serverStreams, &fakeClientStream{
+			err: status.Error(codes.Internal, "boom"),
+		})
+		assert.ErrorContains(t, stream.RecvMsg(&emptypb.Empty{}), "boom")
+		record := handler.wait(t)
+		assert.Equal(t, "gRPCClient Internal Stream", record.Message)
+		assert.Equal(t, slog.LevelError, record.Level)
+	})
+
+	t.Run("single response", func(t *testing.T) {
+		handler := captureLogs(t)
+		stream := newStream(context.Background(), t, &grpc.StreamDesc{ClientStreams: true}, &fakeClientStream{
+			responses: 1,
+		})
+		assert.NilError(t, stream.SendMsg(&emptypb.Empty{}))
+		assert.NilError(t, stream.RecvMsg(&emptypb.Empty{}))
+		record := handler.wait(t)
+		assert.Equal(t, "gRPCClient OK Stream", record.Message)
+		assert.Equal(t, int64(1), attr(record, "sentMessages").Int64())
+		assert.Equal(t, int64(1), attr(record, "receivedMessages").Int64())
+	})
+
+	t.Run("abandoned", func(t *testing.T) {
+		handler := captureLogs(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		stream := newStream(ctx, t, serverStreams, &fakeClientStream{responses: 2})
+		assert.NilError(t, stream.RecvMsg(&emptypb.Empty{}))
+		// The caller stops receiving before the final status, and cancels the context.
+		cancel()
+		record := handler.wait(t)
+		assert.Equal(t, "gRPCClient Canceled Stream", record.Message)
+		assert.Equal(t, slog.LevelWarn, record.Level)
+		assert.Equal(t, int64(1), attr(record, "receivedMessages").Int64())
+	})
+
+	t.Run("streamer error", func(t *testing.T) {
+		handler := captureLogs(t)
+		var middleware Middleware
+		_, err := middleware.GRPCStreamClientInterceptor(
+			context.Background(),
+			serverStreams,
+			nil,
+			fullMethod,
+			func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+				return nil, status.Error(codes.Unavailable, "unavailable")
+			},
+		)
+		assert.ErrorContains(t, err, "unavailable")
+		record := handler.wait(t)
+		assert.Equal(t, "gRPCClient Unavailable Stream", record.Message)
+	})
+}
+
+func newStream(
+	ctx context.Context,
+	t *testing.T,
+	desc *grpc.StreamDesc,
+	clientStream grpc.ClientStream,
+) grpc.ClientStream {
+	t.Helper()
+	var middleware Middleware
+	stream, err := middleware.GRPCStreamClientInterceptor(
+		ctx,
+		desc,
+		nil,
+		"/test.Service/Stream",
+		func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+			return clientStream, nil
+		},
+	)
+	assert.NilError(t, err)
+	return stream
+}
+
+// fakeClientStream is a grpc.ClientStream that receives a number of responses, followed by a final status.
+type fakeClientStream struct {
+	grpc.ClientStream
+	responses int
+	err       error
+}
+
+func (f *fakeClientStream) SendMsg(interface{}) error {
+	return nil
+}
+
+func (f *fakeClientStream) RecvMsg(interface{}) error {
+	if f.responses > 0 {
+		f.responses--
+		return nil
+	}
+	if f.err != nil {
+		return f.err
+	}
+	return io.EOF
+}
+
+// captureLogs replaces the default logger with a recordingHandler for the duration of the test.
+func captureLogs(t *testing.T) *recordingHandler {
+	t.Helper()
+	handler := &recordingHandler{}
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+	slog.SetDefault(slog.New(handler))
+	return handler
+}
+
+// recordingHandler is a slog.Handler that records log records.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *recordingHandler) Handle(_ context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record.Clone())
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *recordingHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func (h *recordingHandler) len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.records)
+}
+
+// wait waits for a record to be logged, and removes it from the handler.
+func (h *recordingHandler) wait(t *testing.T) slog.Record {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		h.mu.Lock()
+		if len(h.records) > 0 {
+			record := h.records[0]
+			h.records = h.records[1:]
+			h.mu.Unlock()
+			return record
+		}
+		h.mu.Unlock()
+	}
+	t.Fatal("timed out waiting for log record")
+	return slog.Record{}
+}
+
+func attr(record slog.Record, key string) slog.Value {
+	var value slog.Value
+	record.Attrs(func(a slog.Attr) bool {
+		if a.Key == key {
+			value = a.Value
+			return false
+		}
+		return true
+	})
+	return value
+}
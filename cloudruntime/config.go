@@ -18,33 +18,33 @@ type configContextKey struct{}
 // Config is the runtime config for the service.
 type Config struct {
 	// Port is the port the service is listening on.
-	Port int `env:"PORT" default:"8080"`
+	Port int `env:"PORT" default:"8080" min:"0" max:"65535" desc:"Port the service is listening on"`
 	// Service is the name of the service.
-	Service string `env:"K_SERVICE"`
+	Service string `env:"K_SERVICE" desc:"Name of the service"`
 	// Revision of the service, as assigned by a Knative runtime.
-	Revision string `env:"K_REVISION"`
+	Revision string `env:"K_REVISION" desc:"Revision of the service"`
 	// Configuration of the service, as assigned by a Knative runtime.
-	Configuration string `env:"K_CONFIGURATION"`
+	Configuration string `env:"K_CONFIGURATION" desc:"Configuration of the service"`
 	// Job name, if running as a Cloud Run job.
-	Job string `env:"CLOUD_RUN_JOB"`
+	Job string `env:"CLOUD_RUN_JOB" desc:"Job name, if running as a Cloud Run job"`
 	// Execution name, if running as a Cloud Run job.
-	Execution string `env:"CLOUD_RUN_EXECUTION"`
+	Execution string `env:"CLOUD_RUN_EXECUTION" desc:"Execution name, if running as a Cloud Run job"`
 	// TaskIndex of the current task, if running as a Cloud Run job.
-	TaskIndex int `env:"CLOUD_RUN_TASK_INDEX"`
+	TaskIndex int `env:"CLOUD_RUN_TASK_INDEX" desc:"Index of the current task, if running as a Cloud Run job"`
 	// TaskAttempt of the current task, if running as a Cloud Run job.
-	TaskAttempt int `env:"CLOUD_RUN_TASK_ATTEMPT"`
+	TaskAttempt int `env:"CLOUD_RUN_TASK_ATTEMPT" desc:"Attempt of the current task, if running as a Cloud Run job"`
 	// TaskCount of the job, if running as a Cloud Run job.
-	TaskCount int `env:"CLOUD_RUN_TASK_COUNT"`
+	TaskCount int `env:"CLOUD_RUN_TASK_COUNT" desc:"Number of tasks of the job, if running as a Cloud Run job"`
 	// ProjectID is the GCP project ID the service is running in.
 	// In production, defaults to the project where the service is deployed.
-	ProjectID string `env:"GOOGLE_CLOUD_PROJECT"`
+	ProjectID string `env:"GOOGLE_CLOUD_PROJECT" desc:"GCP project ID the service is running in"`
 	// ServiceAccount is the service account used by the service.
 	// In production, defaults to the default service account of the running service.
 	ServiceAccount string
 	// ServiceVersion is the version of the service.
-	ServiceVersion string `env:"SERVICE_VERSION"`
+	ServiceVersion string `env:"SERVICE_VERSION" desc:"Version of the service"`
 	// EnablePubsubTracing, disabled by default, reads trace parent from Pub/Sub message attributes.
-	EnablePubsubTracing bool `env:"ENABLE_PUBSUB_TRACING"`
+	EnablePubsubTracing bool `env:"ENABLE_PUBSUB_TRACING" desc:"Read trace parent from Pub/Sub message attributes"`
 }
 
 // Resolve the runtime config.
@@ -0,0 +1,60 @@
+package cloudruntime
+
+import "context"
+
+// Shard returns the half-open range [start, end) of the n work items assigned to the current Cloud Run job task.
+//
+// Work is split evenly across the tasks of the job, and the first n % TaskCount tasks are assigned one extra item.
+// The range depends only on the task index and the task count, so retried attempts of a task are assigned the
+// same work as the original attempt.
+//
+// When not running as a Cloud Run job, the full range [0, n) is returned.
+func (c *Config) Shard(n int) (start, end int) {
+	if n <= 0 {
+		return 0, 0
+	}
+	taskCount, taskIndex := c.TaskCount, c.TaskIndex
+	if taskCount <= 0 {
+		return 0, n
+	}
+	if taskIndex < 0 || taskIndex >= taskCount {
+		return 0, 0
+	}
+	size, remainder := n/taskCount, n%taskCount
+	start = taskIndex*size + min(taskIndex, remainder)
+	end = start + size
+	if taskIndex < remainder {
+		end++
+	}
+	return start, end
+}
+
+// Shard returns the half-open range [start, end) of the n work items assigned to the current Cloud Run job task.
+//
+// The runtime config is taken from the context when available, and otherwise resolved from the environment.
+// See [Config.Shard] for details on how work is split.
+func Shard(ctx context.Context, n int) (start, end int) {
+	config, ok := GetConfig(ctx)
+	if !ok {
+		config.TaskIndex, _ = TaskIndex()
+		config.TaskCount, _ = TaskCount()
+	}
+	return config.Shard(n)
+}
+
+// ForEachShard calls fn for each of the n work items assigned to the current Cloud Run job task.
+//
+// Iteration stops at the first error returned by fn, or when the context is canceled.
+// See [Shard] for details on how work is split.
+func ForEachShard(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	start, end := Shard(ctx, n)
+	for i := start; i < end; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(ctx, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
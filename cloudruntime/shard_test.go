@@ -0,0 +1,97 @@
+package cloudruntime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConfig_Shard(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		n         int
+		taskIndex int
+		taskCount int
+		start     int
+		end       int
+	}{
+		{name: "not a job", n: 10, start: 0, end: 10},
+		{name: "single task", n: 10, taskIndex: 0, taskCount: 1, start: 0, end: 10},
+		{name: "even first", n: 10, taskIndex: 0, taskCount: 2, start: 0, end: 5},
+		{name: "even last", n: 10, taskIndex: 1, taskCount: 2, start: 5, end: 10},
+		{name: "uneven first", n: 10, taskIndex: 0, taskCount: 3, start: 0, end: 4},
+		{name: "uneven middle", n: 10, taskIndex: 1, taskCount: 3, start: 4, end: 7},
+		{name: "uneven last", n: 10, taskIndex: 2, taskCount: 3, start: 7, end: 10},
+		{name: "more tasks than items", n: 2, taskIndex: 3, taskCount: 4, start: 2, end: 2},
+		{name: "no items", n: 0, taskIndex: 0, taskCount: 4, start: 0, end: 0},
+		{name: "index out of range", n: 10, taskIndex: 4, taskCount: 4, start: 0, end: 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{TaskIndex: tt.taskIndex, TaskCount: tt.taskCount}
+			start, end := config.Shard(tt.n)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+		})
+	}
+
+	t.Run("covers all items", func(t *testing.T) {
+		const n, taskCount = 101, 7
+		seen := make([]int, n)
+		for taskIndex := range taskCount {
+			config := Config{TaskIndex: taskIndex, TaskCount: taskCount}
+			start, end := config.Shard(n)
+			for i := start; i < end; i++ {
+				seen[i]++
+			}
+		}
+		for i, count := range seen {
+			assert.Equal(t, 1, count, "item %d", i)
+		}
+	})
+}
+
+func TestShard(t *testing.T) {
+	t.Run("from context", func(t *testing.T) {
+		ctx := WithConfig(context.Background(), Config{TaskIndex: 1, TaskCount: 2})
+		start, end := Shard(ctx, 10)
+		assert.Equal(t, 5, start)
+		assert.Equal(t, 10, end)
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		setEnv(t, "CLOUD_RUN_TASK_INDEX", "0")
+		setEnv(t, "CLOUD_RUN_TASK_COUNT", "2")
+		start, end := Shard(context.Background(), 10)
+		assert.Equal(t, 0, start)
+		assert.Equal(t, 5, end)
+	})
+}
+
+func TestForEachShard(t *testing.T) {
+	t.Run("visits shard", func(t *testing.T) {
+		ctx := WithConfig(context.Background(), Config{TaskIndex: 2, TaskCount: 3})
+		var visited []int
+		err := ForEachShard(ctx, 10, func(_ context.Context, i int) error {
+			visited = append(visited, i)
+			return nil
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []int{7, 8, 9}, visited)
+	})
+
+	t.Run("stops at error", func(t *testing.T) {
+		ctx := WithConfig(context.Background(), Config{TaskIndex: 0, TaskCount: 1})
+		var visited []int
+		err := ForEachShard(ctx, 10, func(_ context.Context, i int) error {
+			visited = append(visited, i)
+			if i == 1 {
+				return errors.New("boom")
+			}
+			return nil
+		})
+		assert.Error(t, err, "boom")
+		assert.DeepEqual(t, []int{0, 1}, visited)
+	})
+}
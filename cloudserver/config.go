@@ -8,8 +8,40 @@ import (
 type Config struct {
 	// Timeout of all requests to the servers.
 	// Defaults to 10 seconds below the default Cloud Run timeout for managed services.
-	Timeout time.Duration `default:"290s"`
+	Timeout time.Duration `default:"290s" min:"0" desc:"Timeout of all requests to the servers"`
 	// ShutdownTimeout is the maximum duration to wait for in-flight requests
 	// to complete during graceful shutdown.
-	ShutdownTimeout time.Duration `default:"5s"`
+	//
+	// Deprecated: Servers drain in-flight requests for half of the total shutdown budget, see [ShutdownConfig].
+	// When set, ShutdownTimeout further bounds the drain.
+	ShutdownTimeout time.Duration `min:"0" desc:"Deprecated: set SHUTDOWN_TIMEOUT instead"`
+}
+
+// ShutdownConfig provides config for graceful shutdown.
+//
+// Graceful shutdown is split into phases that share a single budget: stop accepting new requests,
+// drain in-flight requests and background work, run shutdown hooks, and finally flush telemetry.
+// Servers drain in-flight requests for at most half of the budget, which leaves the rest for the later phases.
+// The duration of each phase is logged and recorded as a metric. The telemetry phase flushes traces, and is recorded
+// before the final export of metrics, which is not included in its duration.
+type ShutdownConfig struct {
+	// Timeout is the total budget for all phases of graceful shutdown.
+	// Defaults to the 10 seconds that Cloud Run allows between SIGTERM and SIGKILL.
+	// See: https://cloud.google.com/run/docs/container-contract#instance-shutdown
+	Timeout time.Duration `default:"10s" min:"0" desc:"Total budget for all phases of graceful shutdown"`
+}
+
+// AdminConfig provides config for the admin server.
+//
+// The admin server listens on a separate port and serves debugging and runtime introspection endpoints,
+// such as pprof profiles, the effective config, build info, gRPC channelz and a runtime log level switch.
+//
+// The endpoints are unauthenticated, so the admin server is disabled by default on GCE and only listens on localhost.
+type AdminConfig struct {
+	// Enabled indicates if the admin server is enabled.
+	Enabled bool `default:"true" onGCE:"false" desc:"Enable the admin server of unauthenticated debugging endpoints"`
+	// Host is the host the admin server listens on.
+	Host string `default:"localhost" desc:"Host the admin server listens on"`
+	// Port is the port the admin server listens on.
+	Port int `default:"8081" min:"0" max:"65535" desc:"Port the admin server listens on"`
 }
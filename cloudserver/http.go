@@ -13,6 +13,8 @@ import (
 // HTTPServer provides HTTP server middleware.
 func (i *Middleware) HTTPServer(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		i.inFlight.Add(1)
+		defer i.inFlight.Add(-1)
 		defer func() {
 			if r := recover(); r != nil {
 				writer.WriteHeader(http.StatusInternalServerError)
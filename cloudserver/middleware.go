@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"runtime"
 	"runtime/debug"
+	"sync/atomic"
 
 	"go.einride.tech/cloudrunner/clouderror"
 	"go.einride.tech/cloudrunner/cloudrequestlog"
@@ -20,6 +21,13 @@ import (
 type Middleware struct {
 	// Config for the middleware.
 	Config Config
+
+	inFlight atomic.Int64
+}
+
+// InFlightRequests returns the number of requests currently being handled by the middleware.
+func (i *Middleware) InFlightRequests() int64 {
+	return i.inFlight.Load()
 }
 
 // GRPCUnaryServerInterceptor implements grpc.UnaryServerInterceptor.
@@ -29,6 +37,8 @@ func (i *Middleware) GRPCUnaryServerInterceptor(
 	_ *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (resp interface{}, err error) {
+	i.inFlight.Add(1)
+	defer i.inFlight.Add(-1)
 	defer func() {
 		if r := recover(); r != nil {
 			err = clouderror.Wrap(
@@ -64,6 +74,8 @@ func (i *Middleware) GRPCStreamServerInterceptor(
 	_ *grpc.StreamServerInfo,
 	handler grpc.StreamHandler,
 ) (err error) {
+	i.inFlight.Add(1)
+	defer i.inFlight.Add(-1)
 	defer func() {
 		if r := recover(); r != nil {
 			err = clouderror.Wrap(
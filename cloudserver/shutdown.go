@@ -0,0 +1,28 @@
+package cloudserver
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/grpc"
+)
+
+// GracefulStopGRPC gracefully stops the gRPC server, and forcefully stops it if the context is done
+// before all in-flight requests have completed.
+// Returns the error of the context if the server was forcefully stopped.
+func GracefulStopGRPC(ctx context.Context, grpcServer *grpc.Server) error {
+	done := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		slog.WarnContext(ctx, "gRPCServer graceful stop deadline exceeded, forcing stop")
+		grpcServer.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,78 @@
+package cloudserver_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	testproto "github.com/grpc-ecosystem/go-grpc-middleware/testing/testproto"
+	"go.einride.tech/cloudrunner/cloudserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"gotest.tools/v3/assert"
+)
+
+type blockingServer struct {
+	Server
+	started chan struct{}
+	release chan struct{}
+}
+
+// Ping implements mwitkow_testproto.TestServiceServer.
+func (s *blockingServer) Ping(ctx context.Context, _ *testproto.PingRequest) (*testproto.PingResponse, error) {
+	close(s.started)
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+	}
+	return &testproto.PingResponse{}, nil
+}
+
+func TestGracefulStopGRPC(t *testing.T) {
+	t.Run("drained", func(t *testing.T) {
+		server, _, testServer := blockingGRPCSetup(t)
+		close(testServer.release)
+		assert.NilError(t, cloudserver.GracefulStopGRPC(context.Background(), server))
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		server, middleware, testServer := blockingGRPCSetup(t)
+		assert.Equal(t, int64(1), middleware.InFlightRequests())
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := cloudserver.GracefulStopGRPC(ctx, server)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		close(testServer.release)
+	})
+}
+
+// blockingGRPCSetup returns a server with a single in-flight request, blocked until released.
+func blockingGRPCSetup(t *testing.T) (*grpc.Server, *cloudserver.Middleware, *blockingServer) {
+	lis := bufconn.Listen(bufSize)
+	middleware := &cloudserver.Middleware{Config: cloudserver.Config{Timeout: time.Second * 5}}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(middleware.GRPCUnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(middleware.GRPCStreamServerInterceptor),
+	)
+	testServer := &blockingServer{started: make(chan struct{}), release: make(chan struct{})}
+	testproto.RegisterTestServiceServer(server, testServer)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	conn, err := grpc.NewClient(
+		"passthrough://bufnet",
+		grpc.WithContextDialer(bufDialer(lis)),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	client := testproto.NewTestServiceClient(conn)
+	go func() {
+		_, _ = client.Ping(context.Background(), &testproto.PingRequest{})
+	}()
+	<-testServer.started
+	return server, middleware, testServer
+}
@@ -21,15 +21,21 @@ type LoggerConfig struct {
 	// ProjectID of the project the service is running in.
 	ProjectID string
 	// Development indicates if the logger should output human-readable output for development.
-	Development bool `default:"true" onGCE:"false"`
+	Development bool `default:"true" onGCE:"false" desc:"Output human-readable logs for development"`
 	// Level indicates which log level the logger should output at.
-	Level slog.Level `default:"debug" onGCE:"info"`
+	Level slog.Level `default:"debug" onGCE:"info" desc:"Log level to output at"`
 	// ProtoMessageSizeLimit is the maximum size, in bytes, of requests and responses to log.
 	// Messages large than the limit will be truncated.
 	// Default value, 0, means that no messages will be truncated.
-	ProtoMessageSizeLimit int `onGCE:"1024"`
+	ProtoMessageSizeLimit int `onGCE:"1024" desc:"Max size of logged messages, 0 for none"`
 	// ReportErrors indicates if error reports should be logged for errors.
-	ReportErrors bool `onGCE:"true"`
+	ReportErrors bool `onGCE:"true" desc:"Log error reports for errors"`
+	// Leveler, when set, overrides Level and enables changing the log level at runtime, e.g. with a [slog.LevelVar].
+	Leveler slog.Leveler `ignored:"true"`
+	// Service name reported in error reports. Defaults to the K_SERVICE environment variable.
+	Service string `ignored:"true"`
+	// ServiceVersion reported in error reports. Defaults to the SERVICE_VERSION environment variable.
+	ServiceVersion string `ignored:"true"`
 }
 
 // NewHandler creates a new [slog.Handler] with special-handling for Cloud Run.
@@ -37,18 +43,27 @@ func NewHandler(config LoggerConfig) slog.Handler {
 	return newHandler(os.Stdout, config)
 }
 
+// NewWriterHandler creates a new [slog.Handler] with special-handling for Cloud Run, that writes to w.
+func NewWriterHandler(w io.Writer, config LoggerConfig) slog.Handler {
+	return newHandler(w, config)
+}
+
 func newHandler(w io.Writer, config LoggerConfig) slog.Handler {
 	replacer := &attrReplacer{config: config}
+	var level slog.Leveler = config.Level
+	if config.Leveler != nil {
+		level = config.Leveler
+	}
 	var result slog.Handler
 	if config.Development {
 		result = slog.NewTextHandler(w, &slog.HandlerOptions{
-			Level:       config.Level,
+			Level:       level,
 			ReplaceAttr: replacer.replaceAttr,
 		})
 	} else {
 		result = slog.NewJSONHandler(w, &slog.HandlerOptions{
 			AddSource:   true,
-			Level:       config.Level,
+			Level:       level,
 			ReplaceAttr: replacer.replaceAttr,
 		})
 	}
@@ -76,13 +91,11 @@ func (t *handler) Handle(ctx context.Context, record slog.Record) error {
 		// See: https://cloud.google.com/error-reporting/docs/formatting-error-messages#reported-error-example
 		record.AddAttrs(slog.String("@type",
 			"type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"))
-		if service, ok := cloudruntime.Service(); ok {
-			if serviceVersion, ok := cloudruntime.ServiceVersion(); ok {
-				record.AddAttrs(slog.Group("serviceContext",
-					slog.String("service", service),
-					slog.String("version", serviceVersion),
-				))
-			}
+		if service, serviceVersion := t.serviceContext(); service != "" && serviceVersion != "" {
+			record.AddAttrs(slog.Group("serviceContext",
+				slog.String("service", service),
+				slog.String("version", serviceVersion),
+			))
 		}
 
 		if record.PC != 0 {
@@ -101,6 +114,18 @@ func (t *handler) Handle(ctx context.Context, record slog.Record) error {
 	return t.Handler.Handle(ctx, record)
 }
 
+// serviceContext returns the service name and version of error reports, from the config or the environment.
+func (t *handler) serviceContext() (service, serviceVersion string) {
+	service, serviceVersion = t.config.Service, t.config.ServiceVersion
+	if service == "" {
+		service, _ = cloudruntime.Service()
+	}
+	if serviceVersion == "" {
+		serviceVersion, _ = cloudruntime.ServiceVersion()
+	}
+	return service, serviceVersion
+}
+
 type attrReplacer struct {
 	config LoggerConfig
 }
@@ -15,4 +15,16 @@ func TestHandler(t *testing.T) {
 		logger.Info("test")
 		assert.Assert(t, strings.Contains(b.String(), "logging.googleapis.com/sourceLocation"))
 	})
+
+	t.Run("leveler", func(t *testing.T) {
+		var b strings.Builder
+		var level slog.LevelVar
+		level.Set(slog.LevelWarn)
+		logger := slog.New(newHandler(&b, LoggerConfig{Level: slog.LevelDebug, Leveler: &level}))
+		logger.Info("test")
+		assert.Equal(t, "", b.String())
+		level.Set(slog.LevelInfo)
+		logger.Info("test")
+		assert.Assert(t, strings.Contains(b.String(), "test"))
+	})
 }
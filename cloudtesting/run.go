@@ -0,0 +1,138 @@
+package cloudtesting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"go.einride.tech/cloudrunner"
+	"go.einride.tech/cloudrunner/internal/testhook"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// RunContextOption configures a run context created by NewRunContext.
+type RunContextOption func(*runContextConfig)
+
+type runContextConfig struct {
+	env     map[string]string
+	onGCE   bool
+	options []any
+}
+
+// WithEnv sets a config value of the run context, keyed by environment variable.
+func WithEnv(key, value string) RunContextOption {
+	return func(config *runContextConfig) {
+		config.env[key] = value
+	}
+}
+
+// WithOnGCE loads the config of the run context as if running on GCE, with values from onGCE tags.
+func WithOnGCE() RunContextOption {
+	return func(config *runContextConfig) {
+		config.onGCE = true
+	}
+}
+
+// WithRunOptions configures the run context with options for cloudrunner.Run.
+func WithRunOptions(options ...cloudrunner.Option) RunContextOption {
+	return func(config *runContextConfig) {
+		for _, option := range options {
+			config.options = append(config.options, option)
+		}
+	}
+}
+
+// NewRunContext returns a context that can be used in place of a context from cloudrunner.Run.
+//
+// The run context is built from explicit config, set with WithEnv, and the config defaults. Flags and the process
+// environment are never read, and the profiler, telemetry exporters and admin server are not started. Values from
+// onGCE tags are only used with WithOnGCE, also when the test runs on GCE.
+// Logs and spans emitted in the run context are captured in memory by the returned RunRecorder.
+//
+// The run context is shut down, and the shutdown hooks run, when the test and all its subtests complete.
+// Since the default slog logger and the global OpenTelemetry tracer provider are replaced for the duration of the
+// test, NewRunContext must not be used in parallel tests.
+func NewRunContext(t testing.TB, options ...RunContextOption) (context.Context, *RunRecorder) {
+	t.Helper()
+	config := runContextConfig{env: map[string]string{}}
+	for _, option := range options {
+		option(&config)
+	}
+	recorder := &RunRecorder{spans: tracetest.NewSpanRecorder()}
+	previousLogger, previousLogWriter, previousLogFlags := slog.Default(), log.Writer(), log.Flags()
+	previousTracerProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder.spans))
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	t.Cleanup(func() {
+		_ = tracerProvider.Shutdown(context.Background())
+		otel.SetTracerProvider(previousTracerProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		slog.SetDefault(previousLogger)
+		log.SetOutput(previousLogWriter)
+		log.SetFlags(previousLogFlags)
+	})
+	ctx, shutdown, err := testhook.NewRunContext(context.Background(), testhook.Config{
+		Env:       config.env,
+		OnGCE:     config.onGCE,
+		LogWriter: &recorder.logs,
+		Options:   config.options,
+	})
+	if err != nil {
+		t.Fatalf("cloudtesting.NewRunContext: %v", err)
+	}
+	t.Cleanup(shutdown)
+	return ctx, recorder
+}
+
+// RunRecorder records logs and spans emitted in a run context created by NewRunContext.
+type RunRecorder struct {
+	logs  logBuffer
+	spans *tracetest.SpanRecorder
+}
+
+// LogEntries returns the log entries emitted so far, as decoded from the Cloud Logging JSON format.
+func (r *RunRecorder) LogEntries() []map[string]any {
+	return r.logs.entries()
+}
+
+// Spans returns the spans that have ended so far.
+func (r *RunRecorder) Spans() []sdktrace.ReadOnlySpan {
+	return r.spans.Ended()
+}
+
+// logBuffer is a concurrency-safe buffer of JSON log lines.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) entries() []map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var result []map[string]any
+	for line := range bytes.Lines(b.buf.Bytes()) {
+		var entry map[string]any
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package cloudtesting_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"go.einride.tech/cloudrunner"
+	"go.einride.tech/cloudrunner/cloudconfig"
+	"go.einride.tech/cloudrunner/cloudtesting"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gotest.tools/v3/assert"
+)
+
+type greeterServer struct {
+	helloworld.UnimplementedGreeterServer
+}
+
+func (greeterServer) SayHello(context.Context, *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	return nil, status.Error(codes.NotFound, "not found")
+}
+
+func TestNewRunContext(t *testing.T) {
+	var shutdownCalled bool
+	t.Cleanup(func() {
+		assert.Assert(t, shutdownCalled)
+	})
+	ctx, recorder := cloudtesting.NewRunContext(
+		t,
+		cloudtesting.WithEnv("K_SERVICE", "test-service"),
+		cloudtesting.WithRunOptions(
+			cloudrunner.WithShutdownHook("test", func(context.Context) error {
+				shutdownCalled = true
+				return nil
+			}),
+		),
+	)
+	assert.Equal(t, "test-service", cloudrunner.Runtime(ctx).Service)
+	grpcServer := cloudrunner.NewGRPCServer(ctx)
+	helloworld.RegisterGreeterServer(grpcServer, greeterServer{})
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, conn.Close())
+	})
+	_, err = helloworld.NewGreeterClient(conn).SayHello(ctx, &helloworld.HelloRequest{Name: "world"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	var requestLog map[string]any
+	for _, entry := range recorder.LogEntries() {
+		if entry["message"] == "gRPCServer NotFound SayHello" {
+			requestLog = entry
+		}
+	}
+	assert.Assert(t, requestLog != nil)
+	assert.Equal(t, "WARNING", requestLog["severity"])
+	assert.Equal(t, "NotFound", requestLog["code"])
+	assert.Equal(t, "helloworld.Greeter", requestLog["service"])
+	assert.Assert(t, len(recorder.Spans()) > 0)
+}
+
+func TestNewRunContext_secretResolver(t *testing.T) {
+	var config struct {
+		Token  string `secret:"true"`
+		APIKey string `secretRef:"projects/p/secrets/api-key"`
+	}
+	cloudtesting.NewRunContext(
+		t,
+		cloudtesting.WithEnv("TOKEN", "sm://projects/p/secrets/token/versions/1"),
+		cloudtesting.WithRunOptions(
+			cloudrunner.WithConfig("test", &config),
+			cloudrunner.WithSecretResolver(cloudconfig.MapSecretResolver{
+				"projects/p/secrets/token/versions/1": "token-value",
+				"projects/p/secrets/api-key":          "api-key-value",
+			}),
+		),
+	)
+	assert.Equal(t, "token-value", config.Token)
+	assert.Equal(t, "api-key-value", config.APIKey)
+}
+
+func TestNewRunContext_onGCE(t *testing.T) {
+	type spec struct {
+		Value string `default:"default" onGCE:"gce"`
+	}
+
+	t.Run("not on GCE", func(t *testing.T) {
+		var config spec
+		cloudtesting.NewRunContext(t, cloudtesting.WithRunOptions(cloudrunner.WithConfig("test", &config)))
+		assert.Equal(t, "default", config.Value)
+	})
+
+	t.Run("on GCE", func(t *testing.T) {
+		var config spec
+		cloudtesting.NewRunContext(
+			t,
+			cloudtesting.WithOnGCE(),
+			cloudtesting.WithRunOptions(cloudrunner.WithConfig("test", &config)),
+		)
+		assert.Equal(t, "gce", config.Value)
+	})
+}
@@ -13,11 +13,15 @@ import (
 // Deprecated: Use cloudslog.LoggerConfig instead.
 type LoggerConfig struct {
 	// Development indicates if the logger should output human-readable output for development.
-	Development bool `default:"true" onGCE:"false"`
+	Development bool `default:"true" onGCE:"false" profile:"dev=true" desc:"Output human-readable logs for development"`
 	// Level indicates which log level the logger should output at.
-	Level zapcore.Level `default:"debug" onGCE:"info"`
+	Level zapcore.Level `default:"debug" onGCE:"info" profile:"dev=debug" desc:"Log level to output at"`
 	// ReportErrors indicates if error reports should be logged for errors.
-	ReportErrors bool `onGCE:"true"`
+	ReportErrors bool `onGCE:"true" desc:"Log error reports for errors"`
+	// Service name reported in error reports. Defaults to the K_SERVICE environment variable.
+	Service string `ignored:"true"`
+	// ServiceVersion reported in error reports. Defaults to the SERVICE_VERSION environment variable.
+	ServiceVersion string `ignored:"true"`
 }
 
 // NewLogger creates a new Logger.
@@ -44,16 +48,21 @@ func NewLogger(config LoggerConfig) (*zap.Logger, error) {
 		}),
 	}
 	if config.ReportErrors {
-		if service, ok := cloudruntime.Service(); ok {
-			if serviceVersion, ok := cloudruntime.ServiceVersion(); ok {
-				zapOptions = append(zapOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
-					return errorReportingCore{
-						nextCore:       core,
-						serviceName:    service,
-						serviceVersion: serviceVersion,
-					}
-				}))
-			}
+		service, serviceVersion := config.Service, config.ServiceVersion
+		if service == "" {
+			service, _ = cloudruntime.Service()
+		}
+		if serviceVersion == "" {
+			serviceVersion, _ = cloudruntime.ServiceVersion()
+		}
+		if service != "" && serviceVersion != "" {
+			zapOptions = append(zapOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+				return errorReportingCore{
+					nextCore:       core,
+					serviceName:    service,
+					serviceVersion: serviceVersion,
+				}
+			}))
 		}
 	}
 	logger, err := zapConfig.Build(zapOptions...)
@@ -26,6 +26,10 @@ func DialService(ctx context.Context, target string, opts ...grpc.DialOption) (*
 					run.requestLoggerMiddleware.GRPCUnaryClientInterceptor,
 					run.clientMiddleware.GRPCUnaryClientInterceptor,
 				),
+				grpc.WithChainStreamInterceptor(
+					run.requestLoggerMiddleware.GRPCStreamClientInterceptor,
+					run.clientMiddleware.GRPCStreamClientInterceptor,
+				),
 			},
 			opts...,
 		)...,
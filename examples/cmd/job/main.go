@@ -0,0 +1,18 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"go.einride.tech/cloudrunner"
+	"go.einride.tech/cloudrunner/cloudruntime"
+)
+
+func main() {
+	cloudrunner.RunJob(func(ctx context.Context) error {
+		return cloudruntime.ForEachShard(ctx, 100, func(ctx context.Context, i int) error {
+			slog.InfoContext(ctx, "processing item", slog.Int("item", i))
+			return nil
+		})
+	})
+}
@@ -0,0 +1,70 @@
+package cloudrunner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// configOverrides is a repeatable flag of config values that take precedence over the environment.
+type configOverrides map[string]string
+
+// String implements [flag.Value].
+func (o configOverrides) String() string {
+	pairs := make([]string, 0, len(o))
+	for key, value := range o {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements [flag.Value].
+func (o configOverrides) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid config override %q, expected KEY=VALUE", s)
+	}
+	o[key] = value
+	return nil
+}
+
+// dotEnvFilenames is a repeatable flag of .env files to load config values from.
+type dotEnvFilenames []string
+
+// String implements [flag.Value].
+func (f *dotEnvFilenames) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set implements [flag.Value].
+func (f *dotEnvFilenames) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+// helpFormat is the -help flag, used either as a boolean flag or with the format of the usage.
+type helpFormat string
+
+// String implements [flag.Value].
+func (f *helpFormat) String() string {
+	return string(*f)
+}
+
+// Set implements [flag.Value].
+func (f *helpFormat) Set(s string) error {
+	switch s {
+	case "true":
+		*f = "text"
+	case "false":
+		*f = ""
+	default:
+		*f = helpFormat(s)
+	}
+	return nil
+}
+
+// IsBoolFlag allows the flag to be used without a value.
+func (f *helpFormat) IsBoolFlag() bool {
+	return true
+}
@@ -0,0 +1,37 @@
+package cloudrunner
+
+import (
+	"flag"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConfigOverrides(t *testing.T) {
+	t.Parallel()
+	overrides := configOverrides{}
+	assert.NilError(t, overrides.Set("SERVER_TIMEOUT=5s"))
+	assert.NilError(t, overrides.Set("REQUESTLOGGER_CODETOLEVEL=NOT_FOUND=WARN"))
+	assert.NilError(t, overrides.Set("K_SERVICE="))
+	assert.DeepEqual(t, configOverrides{
+		"SERVER_TIMEOUT":            "5s",
+		"REQUESTLOGGER_CODETOLEVEL": "NOT_FOUND=WARN",
+		"K_SERVICE":                 "",
+	}, overrides)
+	assert.Equal(t, "K_SERVICE=,REQUESTLOGGER_CODETOLEVEL=NOT_FOUND=WARN,SERVER_TIMEOUT=5s", overrides.String())
+	assert.ErrorContains(t, overrides.Set("SERVER_TIMEOUT"), "expected KEY=VALUE")
+	assert.ErrorContains(t, overrides.Set("=5s"), "expected KEY=VALUE")
+}
+
+func TestHelpFormat(t *testing.T) {
+	t.Parallel()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var format helpFormat
+	fs.Var(&format, "help", "")
+	assert.NilError(t, fs.Parse([]string{"-help"}))
+	assert.Equal(t, helpFormat("text"), format)
+	assert.NilError(t, fs.Parse([]string{"-help=jsonschema"}))
+	assert.Equal(t, helpFormat("jsonschema"), format)
+	assert.NilError(t, fs.Parse([]string{"-help=false"}))
+	assert.Equal(t, helpFormat(""), format)
+}
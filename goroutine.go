@@ -0,0 +1,144 @@
+package cloudrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// GoOption configures a background goroutine started with Go.
+type GoOption func(*goConfig)
+
+type goConfig struct {
+	restart        bool
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// minRestartBackoff is the minimum backoff between restarts of a background goroutine.
+const minRestartBackoff = 10 * time.Millisecond
+
+// WithRestart configures a background goroutine to be restarted when it returns an error or panics.
+// Restarts are delayed with an exponential backoff, starting at initialBackoff and capped at maxBackoff.
+// Backoffs shorter than 10ms are clamped to 10ms, to avoid restarting a failing goroutine in a hot loop.
+func WithRestart(initialBackoff, maxBackoff time.Duration) GoOption {
+	return func(config *goConfig) {
+		config.restart = true
+		config.initialBackoff = max(initialBackoff, minRestartBackoff)
+		config.maxBackoff = max(maxBackoff, config.initialBackoff)
+	}
+}
+
+// Go runs fn in a supervised background goroutine bound to the lifecycle of Run.
+//
+// Panics in fn are recovered and logged as errors, with a stack trace. When fn fails by returning an error or
+// panicking, the context from Run is canceled and the error is returned from Run, unless fn is configured to be
+// restarted with WithRestart.
+//
+// When the service shuts down, Run cancels the context and waits for all background goroutines to return
+// before running the shutdown hooks.
+func Go(ctx context.Context, name string, fn func(context.Context) error, opts ...GoOption) {
+	run, ok := getRunContext(ctx)
+	if !ok {
+		panic("cloudrunner.Go must be called with a context from cloudrunner.Run")
+	}
+	var config goConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	run.goroutines.start(ctx, name, fn, config)
+}
+
+// goroutineGroup keeps track of supervised background goroutines.
+type goroutineGroup struct {
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	errs   []error
+	cancel context.CancelCauseFunc
+}
+
+func (g *goroutineGroup) start(ctx context.Context, name string, fn func(context.Context) error, config goConfig) {
+	g.wg.Go(func() {
+		err := g.supervise(ctx, name, fn, config)
+		if err == nil || (ctx.Err() != nil && errors.Is(err, ctx.Err())) {
+			return
+		}
+		err = fmt.Errorf("goroutine %s: %w", name, err)
+		slog.ErrorContext(ctx, "goroutine failed", slog.String("goroutine", name), slog.Any("error", err))
+		g.mu.Lock()
+		g.errs = append(g.errs, err)
+		g.mu.Unlock()
+		if g.cancel != nil {
+			g.cancel(err)
+		}
+	})
+}
+
+func (g *goroutineGroup) supervise(
+	ctx context.Context,
+	name string,
+	fn func(context.Context) error,
+	config goConfig,
+) error {
+	backoff := config.initialBackoff
+	for {
+		err := runGoroutine(ctx, name, fn)
+		if err == nil || !config.restart || ctx.Err() != nil {
+			return err
+		}
+		slog.WarnContext(
+			ctx,
+			"restarting goroutine",
+			slog.String("goroutine", name),
+			slog.Duration("backoff", backoff),
+			slog.Any("error", err),
+		)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, config.maxBackoff)
+	}
+}
+
+func runGoroutine(ctx context.Context, name string, fn func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered panic: %v", r)
+			slog.LogAttrs(
+				ctx,
+				slog.LevelError,
+				"recovered panic",
+				slog.String("goroutine", name),
+				slog.Any("error", err),
+				slog.String("stack", string(debug.Stack())),
+			)
+		}
+	}()
+	return fn(ctx)
+}
+
+// wait for all background goroutines to return, or for the context to be done,
+// and return the errors of failed background goroutines.
+func (g *goroutineGroup) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.WarnContext(ctx, "background goroutines still running after shutdown deadline")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.Join(g.errs...)
+}
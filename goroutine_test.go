@@ -0,0 +1,144 @@
+package cloudrunner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGoroutineGroup(t *testing.T) {
+	t.Parallel()
+	t.Run("waits for goroutines", func(t *testing.T) {
+		t.Parallel()
+		var g goroutineGroup
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		g.start(ctx, "worker", func(ctx context.Context) error {
+			defer close(done)
+			<-ctx.Done()
+			return ctx.Err()
+		}, goConfig{})
+		cancel()
+		assert.NilError(t, g.wait(context.Background()))
+		<-done
+	})
+
+	t.Run("error cancels run", func(t *testing.T) {
+		t.Parallel()
+		var g goroutineGroup
+		ctx, cancel := context.WithCancelCause(context.Background())
+		g.cancel = cancel
+		g.start(ctx, "worker", func(context.Context) error {
+			return errors.New("boom")
+		}, goConfig{})
+		<-ctx.Done()
+		assert.Error(t, context.Cause(ctx), "goroutine worker: boom")
+		assert.Error(t, g.wait(context.Background()), "goroutine worker: boom")
+	})
+
+	t.Run("recovers panic", func(t *testing.T) {
+		t.Parallel()
+		var g goroutineGroup
+		g.start(context.Background(), "worker", func(context.Context) error {
+			panic("boom")
+		}, goConfig{})
+		assert.Error(t, g.wait(context.Background()), "goroutine worker: recovered panic: boom")
+	})
+
+	t.Run("restarts", func(t *testing.T) {
+		t.Parallel()
+		var g goroutineGroup
+		ctx, cancel := context.WithCancel(context.Background())
+		var attempts int
+		g.start(ctx, "worker", func(context.Context) error {
+			attempts++
+			if attempts == 3 {
+				cancel()
+				return nil
+			}
+			panic("boom")
+		}, goConfig{restart: true, initialBackoff: time.Millisecond, maxBackoff: time.Millisecond})
+		assert.NilError(t, g.wait(context.Background()))
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("restart backoff is clamped", func(t *testing.T) {
+		t.Parallel()
+		for _, tt := range []struct {
+			name                   string
+			initialBackoff         time.Duration
+			maxBackoff             time.Duration
+			expectedInitialBackoff time.Duration
+			expectedMaxBackoff     time.Duration
+		}{
+			{
+				name:                   "valid",
+				initialBackoff:         time.Second,
+				maxBackoff:             time.Minute,
+				expectedInitialBackoff: time.Second,
+				expectedMaxBackoff:     time.Minute,
+			},
+			{
+				name:                   "zero",
+				expectedInitialBackoff: minRestartBackoff,
+				expectedMaxBackoff:     minRestartBackoff,
+			},
+			{
+				name:                   "negative",
+				initialBackoff:         -time.Second,
+				maxBackoff:             -time.Second,
+				expectedInitialBackoff: minRestartBackoff,
+				expectedMaxBackoff:     minRestartBackoff,
+			},
+			{
+				name:                   "max below initial",
+				initialBackoff:         time.Second,
+				maxBackoff:             time.Millisecond,
+				expectedInitialBackoff: time.Second,
+				expectedMaxBackoff:     time.Second,
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+				var config goConfig
+				WithRestart(tt.initialBackoff, tt.maxBackoff)(&config)
+				assert.Assert(t, config.restart)
+				assert.Equal(t, tt.expectedInitialBackoff, config.initialBackoff)
+				assert.Equal(t, tt.expectedMaxBackoff, config.maxBackoff)
+			})
+		}
+	})
+
+	t.Run("zero restart backoff is not a hot loop", func(t *testing.T) {
+		t.Parallel()
+		var g goroutineGroup
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		var config goConfig
+		WithRestart(0, 0)(&config)
+		var attempts int
+		g.start(ctx, "worker", func(context.Context) error {
+			attempts++
+			return errors.New("boom")
+		}, config)
+		_ = g.wait(context.Background())
+		assert.Assert(t, attempts <= 10, "attempts: %d", attempts)
+	})
+
+	t.Run("wait deadline", func(t *testing.T) {
+		t.Parallel()
+		var g goroutineGroup
+		release := make(chan struct{})
+		defer close(release)
+		g.start(context.Background(), "worker", func(context.Context) error {
+			<-release
+			return nil
+		}, goConfig{})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.NilError(t, g.wait(ctx))
+	})
+}
@@ -7,12 +7,15 @@ import (
 	"net"
 	"time"
 
+	"go.einride.tech/cloudrunner/cloudserver"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 )
 
 // NewGRPCServer creates a new gRPC server preconfigured with middleware for request logging, tracing, etc.
+// When health checks are enabled with WithHealthChecks, the gRPC health service is registered on the server.
 func NewGRPCServer(ctx context.Context, opts ...grpc.ServerOption) *grpc.Server {
 	run, ok := getRunContext(ctx)
 	if !ok {
@@ -50,7 +53,11 @@ func NewGRPCServer(ctx context.Context, opts ...grpc.ServerOption) *grpc.Server
 	)
 	serverOptions = append(serverOptions, run.grpcServerOptions...)
 	serverOptions = append(serverOptions, opts...)
-	return grpc.NewServer(serverOptions...)
+	grpcServer := grpc.NewServer(serverOptions...)
+	if run.healthChecks {
+		grpc_health_v1.RegisterHealthServer(grpcServer, run.health.Server)
+	}
+	return grpcServer
 }
 
 // ListenGRPC binds a listener on the configured port and listens for gRPC requests.
@@ -68,11 +75,23 @@ func ListenGRPC(ctx context.Context, grpcServer *grpc.Server) error {
 	if err != nil {
 		return err
 	}
+	shutdown := make(chan struct{})
 	go func() {
+		defer close(shutdown)
 		<-ctx.Done()
+		drainCtx, cancel := run.drainContext(run.beginShutdown(ctx))
+		defer cancel()
 		slog.InfoContext(ctx, "gRPCServer shutting down")
-		grpcServer.GracefulStop()
+		if err := cloudserver.GracefulStopGRPC(drainCtx, grpcServer); err != nil {
+			run.logDrainDeadlineExceeded(ctx, "gRPCServer")
+		}
 	}()
 	slog.InfoContext(ctx, "gRPCServer listening", slog.String("address", address))
-	return grpcServer.Serve(listener)
+	if err := grpcServer.Serve(run.trackListener(ctx, listener)); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		<-shutdown
+	}
+	return nil
 }
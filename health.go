@@ -0,0 +1,107 @@
+package cloudrunner
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Paths of the HTTP health endpoints served by NewHTTPServer when health checks are enabled.
+const (
+	livenessPath  = "/healthz"
+	readinessPath = "/readyz"
+)
+
+// WithHealthChecks configures the run context to serve health checks.
+//
+// When enabled, NewGRPCServer registers the gRPC health service (grpc.health.v1.Health), and NewHTTPServer serves
+// a liveness endpoint on /healthz and a readiness endpoint on /readyz. Both are driven by the same health state,
+// which is updated with [SetServingStatus].
+//
+// The health state switches to NOT_SERVING when the context from Run is canceled, before the servers are stopped.
+func WithHealthChecks() Option {
+	return func(run *runContext) {
+		run.healthChecks = true
+	}
+}
+
+// SetServingStatus sets the serving status of a service, and is safe to call concurrently.
+// The empty service name is the overall health of the server.
+//
+// Once the context from Run has been canceled, all services are NOT_SERVING and further updates are ignored.
+func SetServingStatus(
+	ctx context.Context,
+	service string,
+	servingStatus grpc_health_v1.HealthCheckResponse_ServingStatus,
+) {
+	run, ok := getRunContext(ctx)
+	if !ok {
+		panic("cloudrunner.SetServingStatus must be called with a context from cloudrunner.Run")
+	}
+	run.health.SetServingStatus(service, servingStatus)
+}
+
+// healthState is the shared health state of the gRPC and HTTP servers.
+type healthState struct {
+	*health.Server
+	shutdownOnce sync.Once
+}
+
+func newHealthState() *healthState {
+	return &healthState{Server: health.NewServer()}
+}
+
+// shutdown sets all services to NOT_SERVING and ignores future updates.
+func (h *healthState) shutdown(ctx context.Context) {
+	h.shutdownOnce.Do(func() {
+		slog.InfoContext(ctx, "health status NOT_SERVING")
+		h.Shutdown()
+	})
+}
+
+// HTTPServer serves the health endpoints, and passes on all other requests to the next handler.
+// The health endpoints are served outside of the middleware chain, to not log health probes as requests.
+func (h *healthState) HTTPServer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case livenessPath:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(grpc_health_v1.HealthCheckResponse_SERVING.String()))
+		case readinessPath:
+			servingStatus := h.servingStatus(r.Context(), r.URL.Query().Get("service"))
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			switch servingStatus {
+			case grpc_health_v1.HealthCheckResponse_SERVING:
+				w.WriteHeader(http.StatusOK)
+			case grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN:
+				w.WriteHeader(http.StatusNotFound)
+			default:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			_, _ = w.Write([]byte(servingStatus.String()))
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func (h *healthState) servingStatus(
+	ctx context.Context,
+	service string,
+) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	response, err := h.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		return grpc_health_v1.HealthCheckResponse_UNKNOWN
+	}
+	return response.GetStatus()
+}
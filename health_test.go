@@ -0,0 +1,53 @@
+package cloudrunner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestHealthState_HTTPServer(t *testing.T) {
+	t.Parallel()
+	serve := func(h *healthState, target string) *httptest.ResponseRecorder {
+		handler := h.HTTPServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder
+	}
+
+	t.Run("passes on other requests", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, http.StatusTeapot, serve(newHealthState(), "/foo").Code)
+	})
+
+	t.Run("ready", func(t *testing.T) {
+		t.Parallel()
+		h := newHealthState()
+		assert.Equal(t, http.StatusOK, serve(h, "/healthz").Code)
+		assert.Equal(t, http.StatusOK, serve(h, "/readyz").Code)
+	})
+
+	t.Run("service not serving", func(t *testing.T) {
+		t.Parallel()
+		h := newHealthState()
+		h.SetServingStatus("db", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+		assert.Equal(t, http.StatusOK, serve(h, "/readyz").Code)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(h, "/readyz?service=db").Code)
+		assert.Equal(t, http.StatusNotFound, serve(h, "/readyz?service=unknown").Code)
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		t.Parallel()
+		h := newHealthState()
+		h.shutdown(context.Background())
+		h.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+		assert.Equal(t, http.StatusOK, serve(h, "/healthz").Code)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(h, "/readyz").Code)
+	})
+}
@@ -0,0 +1,31 @@
+package cloudrunner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.einride.tech/cloudrunner/cloudclient"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewHTTPClient creates a new HTTP client for calls to another service, using the default service account's
+// Google ID Token authentication for the audience.
+//
+// Requests are traced, logged, retried when idempotent and time out according to the client config.
+func NewHTTPClient(ctx context.Context, audience string) (*http.Client, error) {
+	run, ok := getRunContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("cloudrunner.NewHTTPClient %s: must be called with a context from cloudrunner.Run", audience)
+	}
+	transport, err := cloudclient.NewHTTPTransport(
+		ctx,
+		audience,
+		run.config.Client,
+		otelhttp.NewTransport(http.DefaultTransport),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cloudrunner.NewHTTPClient: %w", err)
+	}
+	return &http.Client{Transport: run.requestLoggerMiddleware.HTTPClient(transport)}, nil
+}
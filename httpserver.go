@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"strings"
 
@@ -16,6 +17,7 @@ import (
 type HTTPMiddleware = func(http.Handler) http.Handler
 
 // NewHTTPServer creates a new HTTP server preconfigured with middleware for request logging, tracing, etc.
+// When health checks are enabled with WithHealthChecks, the server also serves /healthz and /readyz.
 func NewHTTPServer(ctx context.Context, handler http.Handler, middlewares ...HTTPMiddleware) *http.Server {
 	if handler == nil {
 		panic("cloudrunner.NewHTTPServer: handler must not be nil")
@@ -44,12 +46,13 @@ func NewHTTPServer(ctx context.Context, handler http.Handler, middlewares ...HTT
 		run.securityHeadersMiddleware.HTTPServer,
 		run.serverMiddleware.HTTPServer,
 	)
+	handler = cloudserver.ChainHTTPMiddleware(handler, append(defaultMiddlewares, middlewares...)...)
+	if run.healthChecks {
+		handler = run.health.HTTPServer(handler)
+	}
 	return &http.Server{
-		Addr: fmt.Sprintf(":%d", run.config.Runtime.Port),
-		Handler: cloudserver.ChainHTTPMiddleware(
-			handler,
-			append(defaultMiddlewares, middlewares...)...,
-		),
+		Addr:              fmt.Sprintf(":%d", run.config.Runtime.Port),
+		Handler:           handler,
 		ReadTimeout:       run.serverMiddleware.Config.Timeout,
 		ReadHeaderTimeout: run.serverMiddleware.Config.Timeout,
 		WriteTimeout:      run.serverMiddleware.Config.Timeout,
@@ -82,24 +85,33 @@ func ListenHTTP(ctx context.Context, httpServer *http.Server) error {
 	if !ok {
 		return fmt.Errorf("cloudrunner.ListenHTTP: must be called with a context from cloudrunner.Run")
 	}
-	shutdownTimeout := run.serverMiddleware.Config.ShutdownTimeout
+	address := httpServer.Addr
+	if address == "" {
+		address = ":http"
+	}
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
 	shutdown := make(chan struct{})
-	//nolint:gosec // G118: intentional use of context.Background for graceful shutdown after parent context cancellation
 	go func() {
 		<-ctx.Done()
-		slog.InfoContext(ctx, "HTTPServer shutting down")
-
-		shutdownContext, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		drainCtx, cancel := run.drainContext(run.beginShutdown(ctx))
 		defer cancel()
-
+		slog.InfoContext(ctx, "HTTPServer shutting down")
 		httpServer.SetKeepAlivesEnabled(false)
-		if err := httpServer.Shutdown(shutdownContext); err != nil {
-			slog.ErrorContext(ctx, "HTTPServer shutdown error", slog.Any("error", err))
+		if err := httpServer.Shutdown(drainCtx); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				run.logDrainDeadlineExceeded(ctx, "HTTPServer")
+				_ = httpServer.Close()
+			} else {
+				slog.ErrorContext(ctx, "HTTPServer shutdown error", slog.Any("error", err))
+			}
 		}
 		close(shutdown)
 	}()
 	slog.InfoContext(ctx, "HTTPServer listening", slog.String("address", httpServer.Addr))
-	err := httpServer.ListenAndServe()
+	err = httpServer.Serve(run.trackListener(ctx, listener))
 	if errors.Is(err, http.ErrServerClosed) && ctx.Err() != nil {
 		<-shutdown
 	} else if err != nil {
@@ -0,0 +1,25 @@
+// Package testhook provides hooks into package cloudrunner for package cloudtesting.
+package testhook
+
+import (
+	"context"
+	"io"
+)
+
+// Config configures a run context created for testing.
+type Config struct {
+	// Env contains config values keyed by environment variable. The process environment is never read.
+	Env map[string]string
+	// OnGCE loads config as if running on GCE, with values from onGCE tags. GCE is never detected.
+	OnGCE bool
+	// LogWriter is the writer that logs are written to, as JSON.
+	LogWriter io.Writer
+	// Options contains cloudrunner.Option values to configure the run context with.
+	Options []any
+}
+
+// NewRunContext creates a run context from explicit config, and returns a function that shuts it down.
+// Set by package cloudrunner.
+//
+//nolint:gochecknoglobals // set by package cloudrunner to avoid exporting test-only API
+var NewRunContext func(ctx context.Context, config Config) (context.Context, func(), error)
@@ -0,0 +1,77 @@
+package cloudrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Exit codes used by RunJob.
+const (
+	// jobExitCodeFailure is the exit code of a failed task, which makes Cloud Run retry the task.
+	jobExitCodeFailure = 1
+	// jobExitCodeTerminated is the exit code of a task terminated by a signal (128 + SIGTERM).
+	jobExitCodeTerminated = 143
+)
+
+// RunJob runs a Cloud Run job task and exits the process.
+//
+// Configuration of the job is loaded from the environment, as for [Run]. The start and end of the task is logged
+// together with the job execution, task index and task attempt. The error returned by fn is mapped to the exit code
+// of the process, which determines if Cloud Run considers the task failed and retries it.
+//
+// Use [cloudruntime.Shard] and [cloudruntime.ForEachShard] to split work across the tasks of the job.
+//
+// See: https://cloud.google.com/run/docs/container-contract#jobs
+func RunJob(fn func(context.Context) error, options ...Option) {
+	var logged bool
+	err := Run(func(ctx context.Context) error {
+		logged = true
+		return runTask(ctx, fn)
+	}, options...)
+	if err != nil && !logged {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(jobExitCode(err))
+}
+
+func runTask(ctx context.Context, fn func(context.Context) error) error {
+	runtime := Runtime(ctx)
+	attrs := []any{
+		slog.String("job", runtime.Job),
+		slog.String("execution", runtime.Execution),
+		slog.Int("taskIndex", runtime.TaskIndex),
+		slog.Int("taskAttempt", runtime.TaskAttempt),
+		slog.Int("taskCount", runtime.TaskCount),
+	}
+	startTime := time.Now()
+	slog.InfoContext(ctx, "task started", attrs...)
+	err := fn(ctx)
+	attrs = append(attrs, slog.Duration("duration", time.Since(startTime)))
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		if jobExitCode(err) == jobExitCodeTerminated {
+			slog.WarnContext(ctx, "task terminated", attrs...)
+		} else {
+			slog.ErrorContext(ctx, "task failed", attrs...)
+		}
+		return err
+	}
+	slog.InfoContext(ctx, "task completed", attrs...)
+	return nil
+}
+
+// jobExitCode returns the process exit code for a job task that returned the provided error.
+func jobExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, context.Canceled):
+		return jobExitCodeTerminated
+	default:
+		return jobExitCodeFailure
+	}
+}
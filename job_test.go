@@ -0,0 +1,30 @@
+package cloudrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestJobExitCode(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "nil", err: nil, expected: 0},
+		{name: "error", err: errors.New("boom"), expected: 1},
+		{name: "canceled", err: context.Canceled, expected: 143},
+		{name: "wrapped canceled", err: fmt.Errorf("boom: %w", context.Canceled), expected: 143},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, expected: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, jobExitCode(tt.err))
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package cloudrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// HookOption configures a lifecycle hook.
+type HookOption func(*hook)
+
+// WithHookTimeout sets the maximum duration a lifecycle hook is allowed to run for.
+// By default, a hook is only bounded by the deadline of the lifecycle phase it runs in.
+func WithHookTimeout(timeout time.Duration) HookOption {
+	return func(h *hook) {
+		h.timeout = timeout
+	}
+}
+
+// WithStartHook configures a hook to run before the service function is called.
+// Start hooks run in the order they were registered. If a start hook fails, Run returns
+// with an error and the shutdown hooks registered so far are run.
+func WithStartHook(name string, fn func(context.Context) error, opts ...HookOption) Option {
+	return func(run *runContext) {
+		run.lifecycle.onStart(newHook(name, fn, opts))
+	}
+}
+
+// WithShutdownHook configures a hook to run when the service shuts down.
+// See [OnShutdown] for details on ordering.
+func WithShutdownHook(name string, fn func(context.Context) error, opts ...HookOption) Option {
+	return func(run *runContext) {
+		run.lifecycle.onShutdown(newHook(name, fn, opts))
+	}
+}
+
+// OnShutdown registers a hook to run when the service shuts down, and is safe to call concurrently.
+//
+// Shutdown hooks run in reverse order of registration (LIFO) after the service function has returned,
+// and before telemetry exporters are flushed, so that telemetry emitted by the hooks is still exported.
+func OnShutdown(ctx context.Context, name string, fn func(context.Context) error, opts ...HookOption) {
+	run, ok := getRunContext(ctx)
+	if !ok {
+		panic("cloudrunner.OnShutdown must be called with a context from cloudrunner.Run")
+	}
+	run.lifecycle.onShutdown(newHook(name, fn, opts))
+}
+
+type hook struct {
+	name    string
+	fn      func(context.Context) error
+	timeout time.Duration
+}
+
+func newHook(name string, fn func(context.Context) error, opts []HookOption) hook {
+	h := hook{name: name, fn: fn}
+	for _, opt := range opts {
+		opt(&h)
+	}
+	return h
+}
+
+func (h *hook) run(ctx context.Context) error {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	return h.fn(ctx)
+}
+
+// lifecycle keeps track of start and shutdown hooks.
+type lifecycle struct {
+	mu            sync.Mutex
+	startHooks    []hook
+	shutdownHooks []hook
+}
+
+func (l *lifecycle) onStart(h hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.startHooks = append(l.startHooks, h)
+}
+
+func (l *lifecycle) onShutdown(h hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.shutdownHooks = append(l.shutdownHooks, h)
+}
+
+// start runs the start hooks in order of registration, and stops at the first failing hook.
+func (l *lifecycle) start(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.startHooks
+	l.startHooks = nil
+	l.mu.Unlock()
+	for _, h := range hooks {
+		startTime := time.Now()
+		slog.DebugContext(ctx, "running start hook", slog.String("hook", h.name))
+		if err := h.run(ctx); err != nil {
+			return fmt.Errorf("start hook %s: %w", h.name, err)
+		}
+		slog.InfoContext(
+			ctx,
+			"ran start hook",
+			slog.String("hook", h.name),
+			slog.Duration("duration", time.Since(startTime)),
+		)
+	}
+	return nil
+}
+
+// shutdown runs the shutdown hooks in reverse order of registration.
+// All hooks are run, even if previous hooks fail.
+func (l *lifecycle) shutdown(ctx context.Context) error {
+	var errs []error
+	for {
+		l.mu.Lock()
+		if len(l.shutdownHooks) == 0 {
+			l.mu.Unlock()
+			break
+		}
+		h := l.shutdownHooks[len(l.shutdownHooks)-1]
+		l.shutdownHooks = l.shutdownHooks[:len(l.shutdownHooks)-1]
+		l.mu.Unlock()
+		startTime := time.Now()
+		slog.DebugContext(ctx, "running shutdown hook", slog.String("hook", h.name))
+		if err := h.run(ctx); err != nil {
+			slog.WarnContext(
+				ctx,
+				"shutdown hook failed",
+				slog.String("hook", h.name),
+				slog.Duration("duration", time.Since(startTime)),
+				slog.Any("error", err),
+			)
+			errs = append(errs, fmt.Errorf("shutdown hook %s: %w", h.name, err))
+			continue
+		}
+		slog.InfoContext(
+			ctx,
+			"ran shutdown hook",
+			slog.String("hook", h.name),
+			slog.Duration("duration", time.Since(startTime)),
+		)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package cloudrunner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLifecycle(t *testing.T) {
+	t.Parallel()
+	t.Run("start hooks run in order", func(t *testing.T) {
+		t.Parallel()
+		var l lifecycle
+		var calls []string
+		for _, name := range []string{"a", "b", "c"} {
+			l.onStart(newHook(name, func(context.Context) error {
+				calls = append(calls, name)
+				return nil
+			}, nil))
+		}
+		assert.NilError(t, l.start(context.Background()))
+		assert.DeepEqual(t, []string{"a", "b", "c"}, calls)
+	})
+
+	t.Run("start hooks stop at first error", func(t *testing.T) {
+		t.Parallel()
+		var l lifecycle
+		var calls []string
+		l.onStart(newHook("a", func(context.Context) error {
+			calls = append(calls, "a")
+			return errors.New("boom")
+		}, nil))
+		l.onStart(newHook("b", func(context.Context) error {
+			calls = append(calls, "b")
+			return nil
+		}, nil))
+		assert.Error(t, l.start(context.Background()), "start hook a: boom")
+		assert.DeepEqual(t, []string{"a"}, calls)
+	})
+
+	t.Run("shutdown hooks run in reverse order", func(t *testing.T) {
+		t.Parallel()
+		var l lifecycle
+		var calls []string
+		for _, name := range []string{"a", "b", "c"} {
+			l.onShutdown(newHook(name, func(context.Context) error {
+				calls = append(calls, name)
+				return nil
+			}, nil))
+		}
+		assert.NilError(t, l.shutdown(context.Background()))
+		assert.DeepEqual(t, []string{"c", "b", "a"}, calls)
+	})
+
+	t.Run("shutdown hooks run despite errors", func(t *testing.T) {
+		t.Parallel()
+		var l lifecycle
+		var calls []string
+		l.onShutdown(newHook("a", func(context.Context) error {
+			calls = append(calls, "a")
+			return nil
+		}, nil))
+		l.onShutdown(newHook("b", func(context.Context) error {
+			calls = append(calls, "b")
+			return errors.New("boom")
+		}, nil))
+		assert.Error(t, l.shutdown(context.Background()), "shutdown hook b: boom")
+		assert.DeepEqual(t, []string{"b", "a"}, calls)
+	})
+
+	t.Run("hook timeout", func(t *testing.T) {
+		t.Parallel()
+		var l lifecycle
+		l.onShutdown(newHook("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, []HookOption{WithHookTimeout(10 * time.Millisecond)}))
+		err := l.shutdown(context.Background())
+		assert.Assert(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
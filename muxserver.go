@@ -20,11 +20,25 @@ func ListenGRPCHTTP(ctx context.Context, grpcServer *grpc.Server, httpServer *ht
 	if err != nil {
 		return fmt.Errorf("serve gRPC and HTTP: %w", err)
 	}
+	// Begin the shutdown sequence, switching the health state to NOT_SERVING, before the servers start shutting down.
+	serveCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+			run.beginShutdown(ctx)
+			cancel()
+		case <-serveCtx.Done():
+		}
+	}()
 	if err := cloudmux.ServeGRPCHTTP(
-		ctx, l, grpcServer, httpServer,
-		cloudmux.WithShutdownTimeout(run.serverMiddleware.Config.ShutdownTimeout),
+		serveCtx, run.trackListener(ctx, l), grpcServer, httpServer,
+		cloudmux.WithShutdownTimeout(run.drainTimeout()),
 	); err != nil {
 		return fmt.Errorf("serve gRPC and HTTP: %w", err)
 	}
+	if run.serverMiddleware.InFlightRequests() > 0 {
+		run.logDrainDeadlineExceeded(ctx, "gRPC and HTTP server")
+	}
 	return nil
 }
@@ -27,6 +27,24 @@ func WithConfig(name string, config interface{}) Option {
 	}
 }
 
+// WithProfileValues configures config values, keyed by environment variable, for a profile selected by
+// CLOUDRUNNER_PROFILE, such as a higher trace sample probability in a staging profile:
+//
+//	cloudrunner.WithProfileValues("staging", map[string]string{"TRACEEXPORTER_SAMPLEPROBABILITY": "0.1"})
+func WithProfileValues(profile string, values map[string]string) Option {
+	return func(run *runContext) {
+		run.configOptions = append(run.configOptions, cloudconfig.WithProfileValues(profile, values))
+	}
+}
+
+// WithSecretResolver configures the resolver of secret references in config values, such as
+// sm://projects/p/secrets/name/versions/latest. Defaults to resolving secrets from Secret Manager.
+func WithSecretResolver(resolver cloudconfig.SecretResolver) Option {
+	return func(run *runContext) {
+		run.configOptions = append(run.configOptions, cloudconfig.WithSecretResolver(resolver))
+	}
+}
+
 // WithOptions configures the run context with a list of options.
 func WithOptions(options []Option) Option {
 	return func(run *runContext) {
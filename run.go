@@ -10,7 +10,6 @@ import (
 	"os/signal"
 	"runtime/debug"
 	"syscall"
-	"time"
 
 	"go.einride.tech/cloudrunner/cloudclient"
 	"go.einride.tech/cloudrunner/cloudconfig"
@@ -41,6 +40,10 @@ type runConfig struct {
 	Resource cloudotel.ResourceConfig
 	// Server contains server config.
 	Server cloudserver.Config
+	// Admin contains admin server config.
+	Admin cloudserver.AdminConfig
+	// Shutdown contains graceful shutdown config.
+	Shutdown cloudserver.ShutdownConfig
 	// Client contains client config.
 	Client cloudclient.Config
 	// RequestLogger contains request logging config.
@@ -52,22 +55,43 @@ type runConfig struct {
 func Run(fn func(context.Context) error, options ...Option) (err error) {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
-	usage := flag.Bool("help", false, "show help then exit")
+	var usage helpFormat
+	flag.Var(&usage, "help", "show help as text, json, markdown or jsonschema then exit")
 	yamlServiceSpecificationFile := flag.String("config", "", "load environment from a YAML service specification")
+	yamlContainerName := flag.String(
+		"config-container", "", "load environment from the `container` with this name in the YAML service specification",
+	)
 	validate := flag.Bool("validate", false, "validate config then exit")
+	strict := flag.Bool("strict", false, "fail on unknown env and on required config without a source")
+	printConfig := flag.String("print-config", "", "print the resolved config as env, yaml or json then exit")
+	generateYAMLEnv := flag.String(
+		"generate-yaml-env", "", "merge generated env into a YAML service specification `file` (- for stdout) then exit",
+	)
+	overrides := configOverrides{}
+	flag.Var(overrides, "set", "override a config value, as `KEY=VALUE` (repeatable)")
+	var dotEnvFiles dotEnvFilenames
+	flag.Var(&dotEnvFiles, "env-file", "load environment from a .env `file` (repeatable)")
 	flag.Parse()
 	flag.CommandLine.SetOutput(os.Stdout)
-	var run runContext
-	run.otelTraceMiddleware = cloudotel.NewTraceMiddleware()
-	for _, option := range options {
-		option(&run)
+	run := newRunContext(options)
+	if len(overrides) > 0 {
+		run.configOptions = append(run.configOptions, cloudconfig.WithOverrides(overrides))
+	}
+	if *strict {
+		run.configOptions = append(run.configOptions, cloudconfig.WithStrict())
+	}
+	for _, filename := range dotEnvFiles {
+		run.configOptions = append(run.configOptions, cloudconfig.WithDotEnvFile(filename))
 	}
 	if *yamlServiceSpecificationFile != "" {
 		run.configOptions = append(
 			run.configOptions, cloudconfig.WithYAMLServiceSpecificationFile(*yamlServiceSpecificationFile),
 		)
 	}
-	if *validate {
+	if *yamlContainerName != "" {
+		run.configOptions = append(run.configOptions, cloudconfig.WithYAMLContainerName(*yamlContainerName))
+	}
+	if *validate || *printConfig != "" {
 		run.configOptions = append(
 			run.configOptions,
 			cloudconfig.WithOptionalSecrets(),
@@ -77,44 +101,51 @@ func Run(fn func(context.Context) error, options ...Option) (err error) {
 	if err != nil {
 		return fmt.Errorf("cloudrunner.Run: %w", err)
 	}
-	if *usage {
-		printUsage(flag.CommandLine.Output(), config)
+	if usage != "" {
+		if err := printUsage(flag.CommandLine.Output(), config, string(usage)); err != nil {
+			return fmt.Errorf("cloudrunner.Run: %w", err)
+		}
+		return nil
+	}
+	if *generateYAMLEnv == "-" {
+		if err := config.WriteYAMLEnv(flag.CommandLine.Output()); err != nil {
+			return fmt.Errorf("cloudrunner.Run: %w", err)
+		}
+		return nil
+	} else if *generateYAMLEnv != "" {
+		if err := config.MergeYAMLEnv(*generateYAMLEnv); err != nil {
+			return fmt.Errorf("cloudrunner.Run: %w", err)
+		}
 		return nil
 	}
-	if err := config.Load(); err != nil {
+	if err := config.LoadContext(ctx); err != nil {
 		return fmt.Errorf("cloudrunner.Run: %w", err)
 	}
 	if err := run.config.Runtime.Autodetect(); err != nil { //nolint:staticcheck // SA1019: TODO migrate to Config.Resolve
 		return fmt.Errorf("cloudrunner.Run: %w", err)
 	}
+	if *printConfig != "" {
+		if err := config.PrintConfig(flag.CommandLine.Output(), *printConfig); err != nil {
+			return fmt.Errorf("cloudrunner.Run: %w", err)
+		}
+		return nil
+	}
 	if *validate {
 		return nil
 	}
-
-	run.traceMiddleware.ProjectID = run.config.Runtime.ProjectID //nolint:staticcheck // SA1019: deprecated
-	if run.traceMiddleware.TraceHook == nil {                    //nolint:staticcheck // SA1019: deprecated
-		run.traceMiddleware.TraceHook = cloudtrace.IDHook //nolint:staticcheck // SA1019: deprecated
-	}
-	run.otelTraceMiddleware.ProjectID = run.config.Runtime.ProjectID //nolint:staticcheck // SA1019: deprecated
-	run.otelTraceMiddleware.EnablePubsubTracing = run.config.Runtime.EnablePubsubTracing
-	run.serverMiddleware.Config = run.config.Server
-	run.requestLoggerMiddleware.Config = run.config.RequestLogger
-	ctx = withRunContext(ctx, &run)
-	ctx = cloudruntime.WithConfig(ctx, run.config.Runtime)
-	logger, err := cloudzap.NewLogger(run.config.Logger) //nolint:staticcheck // SA1019: deprecated, pending removal
+	ctx, err = run.init(ctx)
 	if err != nil {
 		return fmt.Errorf("cloudrunner.Run: %w", err)
 	}
-	run.loggerMiddleware.Logger = logger
-	ctx = cloudzap.WithLogger(ctx, logger) //nolint:staticcheck // SA1019: deprecated, pending removal
 	// Set the global default log/slog logger.
-	slog.SetDefault(slog.New(cloudslog.NewHandler(cloudslog.LoggerConfig{
-		ProjectID:             run.config.Runtime.ProjectID,
-		Development:           run.config.Logger.Development,
-		Level:                 cloudzap.LevelToSlog(run.config.Logger.Level), //nolint:staticcheck // SA1019: deprecated
-		ProtoMessageSizeLimit: run.config.RequestLogger.MessageSizeLimit,
-		ReportErrors:          run.config.Logger.ReportErrors,
-	})))
+	slog.SetDefault(slog.New(cloudslog.NewHandler(run.loggerConfig())))
+	defer func() {
+		cancel()
+		if shutdownErr := run.shutdown(ctx); shutdownErr != nil {
+			err = errors.Join(err, fmt.Errorf("cloudrunner.Run: %w", shutdownErr))
+		}
+		slog.InfoContext(ctx, "goodbye")
+	}()
 	if err := cloudprofiler.Start(run.config.Profiler); err != nil {
 		return fmt.Errorf("cloudrunner.Run: %w", err)
 	}
@@ -126,28 +157,21 @@ func Run(fn func(context.Context) error, options ...Option) (err error) {
 	if err != nil {
 		return fmt.Errorf("cloudrunner.Run: %w", err)
 	}
+	run.telemetryShutdownFuncs = append(run.telemetryShutdownFuncs, stopTraceExporter)
 	stopMetricExporter, err := cloudotel.StartMetricExporter(ctx, run.config.MetricExporter, resource)
 	if err != nil {
 		return fmt.Errorf("cloudrunner.Run: %w", err)
 	}
+	run.stopMetricExporter = stopMetricExporter
 	cloudotel.RegisterErrorHandler(ctx)
+	if run.config.Admin.Enabled {
+		run.startAdmin(ctx, config)
+	}
+	if err := run.lifecycle.start(ctx); err != nil {
+		return fmt.Errorf("cloudrunner.Run: %w", err)
+	}
+	config.LogDeprecatedKeys(ctx)
 	buildInfo, _ := debug.ReadBuildInfo()
-	go func() {
-		<-ctx.Done()
-		// Cloud Run sends a SIGTERM and allows for 10 seconds before it completely shuts down
-		// the instance.
-		// See https://cloud.google.com/run/docs/container-contract#instance-shutdown for more details.
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		slog.InfoContext(ctx, "shutting down")
-		err := errors.Join(
-			stopTraceExporter(shutdownCtx),
-			stopMetricExporter(shutdownCtx),
-		)
-		if err != nil {
-			slog.WarnContext(ctx, "unable to call shutdown routines", slog.Any("error", err))
-		}
-	}()
 	slog.InfoContext(
 		ctx,
 		"up and running",
@@ -155,7 +179,6 @@ func Run(fn func(context.Context) error, options ...Option) (err error) {
 		slog.Any("resource", resource),
 		slog.Any("buildInfo", buildInfo),
 	)
-	defer slog.InfoContext(ctx, "goodbye")
 	defer func() {
 		if r := recover(); r != nil {
 			var msg slog.Attr
@@ -178,9 +201,28 @@ func Run(fn func(context.Context) error, options ...Option) (err error) {
 	return fn(ctx)
 }
 
+func newRunContext(options []Option) *runContext {
+	run := &runContext{
+		otelTraceMiddleware: cloudotel.NewTraceMiddleware(),
+		health:              newHealthState(),
+	}
+	for _, option := range options {
+		option(run)
+	}
+	return run
+}
+
 type runContext struct {
 	config                    runConfig
 	configOptions             []cloudconfig.Option
+	lifecycle                 lifecycle
+	goroutines                goroutineGroup
+	shutdownSequence          shutdownSequence
+	health                    *healthState
+	healthChecks              bool
+	logLevel                  slog.LevelVar
+	telemetryShutdownFuncs    []func(context.Context) error
+	stopMetricExporter        func(context.Context) error
 	grpcServerOptions         []grpc.ServerOption
 	loggerMiddleware          cloudzap.Middleware //nolint:staticcheck // SA1019: deprecated, pending removal
 	serverMiddleware          cloudserver.Middleware
@@ -202,3 +244,56 @@ func getRunContext(ctx context.Context) (*runContext, bool) {
 	result, ok := ctx.Value(runContextKey{}).(*runContext)
 	return result, ok
 }
+
+// init initializes the run context from the loaded config, and returns a child context of the run context.
+func (r *runContext) init(ctx context.Context) (context.Context, error) {
+	r.traceMiddleware.ProjectID = r.config.Runtime.ProjectID //nolint:staticcheck // SA1019: deprecated
+	if r.traceMiddleware.TraceHook == nil {                  //nolint:staticcheck // SA1019: deprecated
+		r.traceMiddleware.TraceHook = cloudtrace.IDHook //nolint:staticcheck // SA1019: deprecated
+	}
+	r.otelTraceMiddleware.ProjectID = r.config.Runtime.ProjectID //nolint:staticcheck // SA1019: deprecated
+	r.otelTraceMiddleware.EnablePubsubTracing = r.config.Runtime.EnablePubsubTracing
+	r.serverMiddleware.Config = r.config.Server
+	r.requestLoggerMiddleware.Config = r.config.RequestLogger
+	r.clientMiddleware.Config = r.config.Client
+	ctx, r.goroutines.cancel = context.WithCancelCause(ctx)
+	ctx = withRunContext(ctx, r)
+	go func(ctx context.Context) {
+		<-ctx.Done()
+		r.beginShutdown(ctx)
+	}(ctx)
+	ctx = cloudruntime.WithConfig(ctx, r.config.Runtime)
+	r.config.Logger.Service = r.config.Runtime.Service
+	r.config.Logger.ServiceVersion = r.config.Runtime.ServiceVersion
+	logger, err := cloudzap.NewLogger(r.config.Logger) //nolint:staticcheck // SA1019: deprecated, pending removal
+	if err != nil {
+		return nil, err
+	}
+	r.loggerMiddleware.Logger = logger
+	ctx = cloudzap.WithLogger(ctx, logger)                      //nolint:staticcheck // SA1019: deprecated, pending removal
+	r.logLevel.Set(cloudzap.LevelToSlog(r.config.Logger.Level)) //nolint:staticcheck // SA1019: deprecated
+	return ctx, nil
+}
+
+// loggerConfig returns the config of the log/slog handler.
+func (r *runContext) loggerConfig() cloudslog.LoggerConfig {
+	return cloudslog.LoggerConfig{
+		ProjectID:             r.config.Runtime.ProjectID,
+		Development:           r.config.Logger.Development,
+		Level:                 r.logLevel.Level(),
+		Leveler:               &r.logLevel,
+		ProtoMessageSizeLimit: r.config.RequestLogger.MessageSizeLimit,
+		ReportErrors:          r.config.Logger.ReportErrors,
+		Service:               r.config.Runtime.Service,
+		ServiceVersion:        r.config.Runtime.ServiceVersion,
+	}
+}
+
+// stopTelemetry flushes and stops the telemetry exporters, except the metric exporter.
+func (r *runContext) stopTelemetry(ctx context.Context) error {
+	errs := make([]error, 0, len(r.telemetryShutdownFuncs))
+	for _, fn := range r.telemetryShutdownFuncs {
+		errs = append(errs, fn(ctx))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+package cloudrunner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.einride.tech/cloudrunner/cloudconfig"
+	"go.einride.tech/cloudrunner/cloudotel"
+	"go.einride.tech/cloudrunner/cloudslog"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"gotest.tools/v3/assert"
+)
+
+func TestRunContext_yamlServiceSpecification(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "service.yaml")
+	assert.NilError(t, os.WriteFile(filename, []byte(`apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: my-service
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          env:
+            - name: SERVICE_VERSION
+              value: v1
+            - name: LOGGER_REPORTERRORS
+              value: "true"
+`), 0o600))
+	run := newRunContext(nil)
+	config, err := cloudconfig.New(
+		"cloudrunner",
+		&run.config,
+		cloudconfig.WithEnv(map[string]string{}),
+		cloudconfig.WithYAMLServiceSpecificationFile(filename),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, config.Load())
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, err = run.init(ctx)
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		cancel()
+		_ = run.shutdown(ctx)
+	})
+	t.Run("resource", func(t *testing.T) {
+		resource, err := cloudotel.NewResourceWithConfig(ctx, cloudotel.ResourceConfig{AllowPartialResource: true})
+		assert.NilError(t, err)
+		serviceName, ok := resource.Set().Value(semconv.ServiceNameKey)
+		assert.Assert(t, ok)
+		assert.Equal(t, "my-service", serviceName.AsString())
+	})
+	t.Run("error report", func(t *testing.T) {
+		var buf bytes.Buffer
+		loggerConfig := run.loggerConfig()
+		loggerConfig.Development = false
+		slog.New(cloudslog.NewWriterHandler(&buf, loggerConfig)).ErrorContext(ctx, "error")
+		var entry struct {
+			ServiceContext struct {
+				Service string `json:"service"`
+				Version string `json:"version"`
+			} `json:"serviceContext"`
+		}
+		assert.NilError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "my-service", entry.ServiceContext.Service)
+		assert.Equal(t, "v1", entry.ServiceContext.Version)
+	})
+}
@@ -0,0 +1,189 @@
+package cloudrunner
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Phases of graceful shutdown.
+const (
+	shutdownPhaseStopAccepting = "stop_accepting"
+	shutdownPhaseDrain         = "drain"
+	shutdownPhaseHooks         = "hooks"
+	shutdownPhaseTelemetry     = "telemetry"
+)
+
+// shutdownSequence keeps track of the shared shutdown budget.
+type shutdownSequence struct {
+	once              sync.Once
+	stopAcceptingOnce sync.Once
+	startTime         time.Time
+	ctx               context.Context
+	cancel            context.CancelFunc
+	mu                sync.Mutex
+	begun             bool
+	listeners         int
+	drainStart        time.Time
+}
+
+// beginShutdown begins the shutdown sequence and returns a context bounded by the total shutdown budget.
+// Safe to call multiple times and concurrently, only the first call begins the sequence.
+func (r *runContext) beginShutdown(ctx context.Context) context.Context {
+	r.shutdownSequence.once.Do(func() {
+		s := &r.shutdownSequence
+		s.startTime = time.Now()
+		if r.config.Shutdown.Timeout > 0 {
+			s.ctx, s.cancel = context.WithTimeout(context.WithoutCancel(ctx), r.config.Shutdown.Timeout)
+		} else {
+			s.ctx, s.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		}
+		slog.InfoContext(ctx, "shutting down", slog.Duration("budget", r.config.Shutdown.Timeout))
+		if r.healthChecks {
+			r.health.shutdown(ctx)
+		}
+		s.mu.Lock()
+		s.begun = true
+		listening := s.listeners > 0
+		s.mu.Unlock()
+		if !listening {
+			r.stopAccepting(ctx)
+		}
+	})
+	return r.shutdownSequence.ctx
+}
+
+// trackListener returns a listener that completes the stop accepting phase of the shutdown sequence when it, and
+// all other tracked listeners, have been closed.
+func (r *runContext) trackListener(ctx context.Context, listener net.Listener) net.Listener {
+	s := &r.shutdownSequence
+	s.mu.Lock()
+	s.listeners++
+	s.mu.Unlock()
+	return &trackedListener{Listener: listener, onClose: func() {
+		s.mu.Lock()
+		s.listeners--
+		stopped := s.begun && s.listeners == 0
+		s.mu.Unlock()
+		if stopped {
+			r.stopAccepting(ctx)
+		}
+	}}
+}
+
+// stopAccepting completes the stop accepting phase of the shutdown sequence, and begins the drain phase.
+func (r *runContext) stopAccepting(ctx context.Context) {
+	s := &r.shutdownSequence
+	s.stopAcceptingOnce.Do(func() {
+		r.recordShutdownPhase(ctx, shutdownPhaseStopAccepting, s.startTime)
+		s.mu.Lock()
+		s.drainStart = time.Now()
+		s.mu.Unlock()
+	})
+}
+
+// trackedListener is a listener that calls onClose once when closed.
+type trackedListener struct {
+	net.Listener
+	closeOnce sync.Once
+	onClose   func()
+}
+
+// Close implements [net.Listener].
+func (l *trackedListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(l.onClose)
+	return err
+}
+
+// drainTimeout returns the maximum duration to wait for servers to drain in-flight requests.
+// Servers get half of the shutdown budget to drain, which leaves the rest for shutdown hooks and telemetry.
+// Returns zero when there is no limit.
+func (r *runContext) drainTimeout() time.Duration {
+	timeout := r.config.Shutdown.Timeout / 2
+	serverTimeout := r.config.Server.ShutdownTimeout //nolint:staticcheck // SA1019: still bounds the drain when set
+	if serverTimeout > 0 && (timeout <= 0 || serverTimeout < timeout) {
+		timeout = serverTimeout
+	}
+	return timeout
+}
+
+// drainContext returns a context for servers to drain in-flight requests, bounded by the shutdown budget.
+func (r *runContext) drainContext(shutdownCtx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := r.drainTimeout(); timeout > 0 {
+		return context.WithTimeout(shutdownCtx, timeout)
+	}
+	return context.WithCancel(shutdownCtx)
+}
+
+// logDrainDeadlineExceeded logs the number of requests still in flight when the drain deadline was exceeded.
+func (r *runContext) logDrainDeadlineExceeded(ctx context.Context, server string) {
+	slog.WarnContext(
+		ctx,
+		"shutdown deadline exceeded with requests in flight",
+		slog.String("server", server),
+		slog.Int64("inFlightRequests", r.serverMiddleware.InFlightRequests()),
+	)
+}
+
+// recordShutdownPhase logs and records a metric for the duration of a completed shutdown phase.
+func (r *runContext) recordShutdownPhase(ctx context.Context, phase string, startTime time.Time) {
+	duration := time.Since(startTime)
+	attrs := []slog.Attr{
+		slog.String("phase", phase),
+		slog.Duration("duration", duration),
+	}
+	if deadline, ok := r.shutdownSequence.ctx.Deadline(); ok {
+		attrs = append(attrs, slog.Duration("remaining", time.Until(deadline)))
+	}
+	slog.LogAttrs(ctx, slog.LevelInfo, "shutdown phase completed", attrs...)
+	histogram, err := otel.Meter("go.einride.tech/cloudrunner").Float64Histogram(
+		"cloudrunner.shutdown.phase.duration",
+		metric.WithDescription("Duration of graceful shutdown phases."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		slog.WarnContext(ctx, "unable to record shutdown phase duration", slog.Any("error", err))
+		return
+	}
+	histogram.Record(ctx, duration.Seconds(), metric.WithAttributes(attribute.String("phase", phase)))
+}
+
+// shutdown completes the shutdown sequence: it waits for in-flight work and background goroutines to drain,
+// runs the shutdown hooks and then flushes telemetry, all within the shared shutdown budget.
+// Returns the errors of failed background goroutines.
+func (r *runContext) shutdown(ctx context.Context) error {
+	shutdownCtx := r.beginShutdown(ctx)
+	defer r.shutdownSequence.cancel()
+	// Servers have stopped accepting new requests when the function passed to Run has returned.
+	r.stopAccepting(ctx)
+	goroutinesErr := r.goroutines.wait(shutdownCtx)
+	r.shutdownSequence.mu.Lock()
+	drainStart := r.shutdownSequence.drainStart
+	r.shutdownSequence.mu.Unlock()
+	r.recordShutdownPhase(ctx, shutdownPhaseDrain, drainStart)
+	hooksStart := time.Now()
+	if err := r.lifecycle.shutdown(shutdownCtx); err != nil {
+		slog.WarnContext(ctx, "unable to call shutdown hooks", slog.Any("error", err))
+	}
+	r.recordShutdownPhase(ctx, shutdownPhaseHooks, hooksStart)
+	telemetryStart := time.Now()
+	if err := r.stopTelemetry(shutdownCtx); err != nil {
+		slog.WarnContext(ctx, "unable to call shutdown routines", slog.Any("error", err))
+	}
+	// The telemetry phase is recorded before the metric exporter is stopped, so that its duration is included in the
+	// final export of metrics.
+	r.recordShutdownPhase(ctx, shutdownPhaseTelemetry, telemetryStart)
+	if r.stopMetricExporter != nil {
+		if err := r.stopMetricExporter(shutdownCtx); err != nil {
+			slog.WarnContext(ctx, "unable to stop metric exporter", slog.Any("error", err))
+		}
+	}
+	return goroutinesErr
+}
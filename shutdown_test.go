@@ -0,0 +1,167 @@
+package cloudrunner
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestRunContext_drainTimeout(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name                  string
+		shutdownTimeout       time.Duration
+		serverShutdownTimeout time.Duration
+		expected              time.Duration
+	}{
+		{
+			name:            "half of shutdown budget",
+			shutdownTimeout: 10 * time.Second,
+			expected:        5 * time.Second,
+		},
+		{
+			name:                  "bounded by deprecated server shutdown timeout",
+			shutdownTimeout:       10 * time.Second,
+			serverShutdownTimeout: time.Second,
+			expected:              time.Second,
+		},
+		{
+			name:                  "deprecated server shutdown timeout above budget",
+			shutdownTimeout:       10 * time.Second,
+			serverShutdownTimeout: time.Minute,
+			expected:              5 * time.Second,
+		},
+		{
+			name:                  "unlimited budget",
+			serverShutdownTimeout: time.Second,
+			expected:              time.Second,
+		},
+		{
+			name:     "unlimited",
+			expected: 0,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			run := newRunContext(nil)
+			run.config.Shutdown.Timeout = tt.shutdownTimeout
+			run.config.Server.ShutdownTimeout = tt.serverShutdownTimeout //nolint:staticcheck // SA1019: deprecated
+			assert.Equal(t, tt.expected, run.drainTimeout())
+		})
+	}
+}
+
+func TestRunContext_stopAccepting(t *testing.T) {
+	t.Parallel()
+	drainStart := func(run *runContext) time.Time {
+		run.shutdownSequence.mu.Lock()
+		defer run.shutdownSequence.mu.Unlock()
+		return run.shutdownSequence.drainStart
+	}
+
+	t.Run("no listeners", func(t *testing.T) {
+		t.Parallel()
+		run := newRunContext(nil)
+		run.beginShutdown(context.Background())
+		assert.Assert(t, !drainStart(run).IsZero())
+	})
+
+	t.Run("waits for listeners to close", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		run := newRunContext(nil)
+		listener1 := run.trackListener(ctx, newTestListener(t))
+		listener2 := run.trackListener(ctx, newTestListener(t))
+		run.beginShutdown(ctx)
+		assert.Assert(t, drainStart(run).IsZero())
+		assert.NilError(t, listener1.Close())
+		// Closing a listener more than once is counted once.
+		_ = listener1.Close()
+		assert.Assert(t, drainStart(run).IsZero())
+		assert.NilError(t, listener2.Close())
+		assert.Assert(t, !drainStart(run).IsZero())
+	})
+
+	t.Run("listeners closed before shutdown", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		run := newRunContext(nil)
+		listener := run.trackListener(ctx, newTestListener(t))
+		assert.NilError(t, listener.Close())
+		assert.Assert(t, drainStart(run).IsZero())
+		run.beginShutdown(ctx)
+		assert.Assert(t, !drainStart(run).IsZero())
+	})
+}
+
+func TestRunContext_beginShutdown_health(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name         string
+		healthChecks bool
+		expected     grpc_health_v1.HealthCheckResponse_ServingStatus
+	}{
+		{name: "health checks", healthChecks: true, expected: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{name: "no health checks", expected: grpc_health_v1.HealthCheckResponse_SERVING},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			run := newRunContext(nil)
+			run.healthChecks = tt.healthChecks
+			run.beginShutdown(ctx)
+			assert.Equal(t, tt.expected, run.health.servingStatus(ctx, ""))
+		})
+	}
+}
+
+// TestRunContext_shutdown_phaseMetrics is not parallel, since it replaces the global meter provider.
+func TestRunContext_shutdown_phaseMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previousMeterProvider := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(previousMeterProvider) })
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	run := newRunContext(nil)
+	var phases []string
+	run.stopMetricExporter = func(ctx context.Context) error {
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &rm); err != nil {
+			return err
+		}
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name != "cloudrunner.shutdown.phase.duration" {
+					continue
+				}
+				for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+					phase, _ := dp.Attributes.Value("phase")
+					phases = append(phases, phase.AsString())
+				}
+			}
+		}
+		return nil
+	}
+	assert.NilError(t, run.shutdown(context.Background()))
+	// All phases are recorded before the metric exporter is stopped.
+	slices.Sort(phases)
+	assert.DeepEqual(
+		t,
+		[]string{shutdownPhaseDrain, shutdownPhaseHooks, shutdownPhaseStopAccepting, shutdownPhaseTelemetry},
+		phases,
+	)
+}
+
+func newTestListener(t *testing.T) net.Listener {
+	t.Helper()
+	listener, err := (&net.ListenConfig{}).Listen(t.Context(), "tcp", "localhost:0")
+	assert.NilError(t, err)
+	return listener
+}
@@ -0,0 +1,63 @@
+package cloudrunner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.einride.tech/cloudrunner/cloudconfig"
+	"go.einride.tech/cloudrunner/cloudslog"
+	"go.einride.tech/cloudrunner/internal/testhook"
+)
+
+//nolint:gochecknoinits // registers the test hook used by package cloudtesting
+func init() {
+	testhook.NewRunContext = newTestRunContext
+}
+
+// newTestRunContext creates a run context from explicit config, without reading flags, the process environment or
+// the GCE metadata server, and without starting the profiler, telemetry exporters or admin server.
+func newTestRunContext(ctx context.Context, config testhook.Config) (context.Context, func(), error) {
+	options := make([]Option, 0, len(config.Options))
+	for _, option := range config.Options {
+		runOption, ok := option.(Option)
+		if !ok {
+			return nil, nil, fmt.Errorf("new test run context: unexpected option type %T", option)
+		}
+		options = append(options, runOption)
+	}
+	run := newRunContext(options)
+	env := config.Env
+	if env == nil {
+		env = map[string]string{}
+	}
+	runConfig, err := cloudconfig.New(
+		"cloudrunner",
+		&run.config,
+		append(run.configOptions, cloudconfig.WithEnv(env), cloudconfig.WithOnGCE(config.OnGCE))...,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("new test run context: %w", err)
+	}
+	if err := runConfig.LoadContext(ctx); err != nil {
+		return nil, nil, fmt.Errorf("new test run context: %w", err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	ctx, err = run.init(ctx)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("new test run context: %w", err)
+	}
+	loggerConfig := run.loggerConfig()
+	loggerConfig.Development = false
+	slog.SetDefault(slog.New(cloudslog.NewWriterHandler(config.LogWriter, loggerConfig)))
+	shutdown := func() {
+		cancel()
+		_ = run.shutdown(ctx)
+	}
+	if err := run.lifecycle.start(ctx); err != nil {
+		shutdown()
+		return nil, nil, fmt.Errorf("new test run context: %w", err)
+	}
+	return ctx, shutdown, nil
+}
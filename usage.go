@@ -12,7 +12,10 @@ import (
 	"go.einride.tech/cloudrunner/cloudruntime"
 )
 
-func printUsage(w io.Writer, config *cloudconfig.Config) {
+func printUsage(w io.Writer, config *cloudconfig.Config, format string) error {
+	if format != "text" {
+		return config.PrintUsageFormat(w, format)
+	}
 	_, _ = fmt.Fprintf(w, "\nUsage of %s:\n\n", path.Base(os.Args[0]))
 	flag.CommandLine.PrintDefaults()
 	_, _ = fmt.Fprintf(w, "\nRuntime configuration of %s:\n\n", path.Base(os.Args[0]))
@@ -28,4 +31,5 @@ func printUsage(w io.Writer, config *cloudconfig.Config) {
 		cloudruntime.ServiceVersionFromLinkerFlags(),
 	)
 	_ = tabs.Flush()
+	return nil
 }